@@ -17,7 +17,9 @@
 package main
 
 import (
+	"bufio"
 	"bytes"
+	"errors"
 	"fmt"
 	"os"
 	"os/signal"
@@ -37,11 +39,29 @@ import (
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/internal/flags"
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/ethereum/go-ethereum/trie"
 	"github.com/olekukonko/tablewriter"
 	"github.com/urfave/cli/v2"
 )
 
+var (
+	zkNodesRootFlag = &cli.StringFlag{
+		Name:     "root",
+		Usage:    "Root hash of the zktrie to export",
+		Required: true,
+	}
+	zkNodesOutFlag = &cli.StringFlag{
+		Name:     "out",
+		Usage:    "Directory to write the exported nodes into",
+		Required: true,
+	}
+	removeGuardFlag = &cli.BoolFlag{
+		Name:  "remove-guard",
+		Usage: "Remove the migration guard instead of installing it",
+	}
+)
+
 var (
 	removedbCommand = &cli.Command{
 		Action:    removeDB,
@@ -69,6 +89,10 @@ Remove blockchain and state databases`,
 			dbExportCmd,
 			dbMetadataCmd,
 			dbCheckStateContentCmd,
+			dbExportZkNodesCmd,
+			dbPrunePreimagesCmd,
+			dbGuardCmd,
+			dbMigrationCleanupCmd,
 		},
 	}
 	dbInspectCmd = &cli.Command{
@@ -184,6 +208,62 @@ WARNING: This is a low-level operation which may cause database corruption!`,
 		}, utils.NetworkFlags, utils.DatabaseFlags),
 		Description: "Exports the specified chain data to an RLP encoded stream, optionally gzip-compressed.",
 	}
+	dbExportZkNodesCmd = &cli.Command{
+		Action: exportZkNodes,
+		Name:   "export-zk-nodes",
+		Usage:  "Exports the raw nodes of a zktrie for offline analysis",
+		Flags: flags.Merge([]cli.Flag{
+			utils.SyncModeFlag,
+			utils.KromaZKTrie,
+			zkNodesRootFlag,
+			zkNodesOutFlag,
+		}, utils.NetworkFlags, utils.DatabaseFlags),
+		Description: `This command walks the zktrie with the given root and writes every node
+into <out>/zknodes-<root>.rlp as an RLP stream of (path, hash, blob) entries,
+where the path is the sequence of branch bits (0 left, 1 right) leading to the
+node and the blob is the raw poseidon node encoding.`,
+	}
+	dbPrunePreimagesCmd = &cli.Command{
+		Action: prunePreimages,
+		Name:   "prune-preimages",
+		Usage:  "Deletes all trie key preimages from the database",
+		Flags: flags.Merge([]cli.Flag{
+			utils.SyncModeFlag,
+		}, utils.NetworkFlags, utils.DatabaseFlags),
+		Description: `This command deletes the preimage table, leaving trie nodes and all other
+chain data untouched. Preimages are required to iterate the keys of a secure
+trie, so only prune them once nothing depends on them anymore (e.g. after
+exporting them with 'geth db export preimage').
+The deletion is flushed in batches and can be interrupted at any time, running
+the command again picks up the remaining preimages.`,
+	}
+	dbGuardCmd = &cli.Command{
+		Action: dbGuard,
+		Name:   "guard",
+		Usage:  "Guards the database against binaries unaware of the state migration",
+		Flags: flags.Merge([]cli.Flag{
+			utils.SyncModeFlag,
+			removeGuardFlag,
+		}, utils.NetworkFlags, utils.DatabaseFlags),
+		Description: `This command raises the advertised database version above anything released
+binaries support, so that a Geth predating the zktrie to MPT state migration
+refuses to open the migrated database instead of corrupting it. The real version
+is kept aside and used by migration-aware binaries.
+Pass --remove-guard to restore the real database version, e.g. to roll back to
+an older binary.`,
+	}
+	dbMigrationCleanupCmd = &cli.Command{
+		Action: dbMigrationCleanup,
+		Name:   "migration-cleanup",
+		Usage:  "Removes the progress metadata left behind by the state migration",
+		Flags: flags.Merge([]cli.Flag{
+			utils.SyncModeFlag,
+		}, utils.NetworkFlags, utils.DatabaseFlags),
+		Description: `This command deletes the keys the zktrie to MPT state migrator stored under
+the 'migration-' namespace to track its progress. The migration receipt and
+the migration guard are kept. If no receipt exists, the migration may not
+have completed and the command asks for confirmation first.`,
+	}
 	dbMetadataCmd = &cli.Command{
 		Action: showMetaData,
 		Name:   "metadata",
@@ -708,6 +788,242 @@ func exportChaindata(ctx *cli.Context) error {
 	return utils.ExportChaindata(ctx.Args().Get(1), kind, exporter(db), stop)
 }
 
+// zkNodeEntry is a single node written by export-zk-nodes.
+type zkNodeEntry struct {
+	Path []byte
+	Hash common.Hash
+	Blob []byte
+}
+
+func exportZkNodes(ctx *cli.Context) error {
+	root, err := hexutil.Decode(ctx.String(zkNodesRootFlag.Name))
+	if err != nil || len(root) != common.HashLength {
+		return fmt.Errorf("invalid root hash %q", ctx.String(zkNodesRootFlag.Name))
+	}
+	stack, cfg := makeConfigNode(ctx)
+	defer stack.Close()
+
+	if !cfg.Eth.Genesis.Config.Zktrie {
+		return errors.New("database is not zktrie based")
+	}
+	db := utils.MakeChainDatabase(ctx, stack, true)
+	defer db.Close()
+
+	triedb := utils.MakeTrieDatabase(ctx, db, false, true, false, true)
+	defer triedb.Close()
+
+	var nodes trie.NodeIterator
+	if cfg.Eth.KromaZKTrie {
+		tr, err := trie.NewZkMerkleStateTrie(common.BytesToHash(root), triedb)
+		if err != nil {
+			return err
+		}
+		nodes, err = tr.NodeIterator(nil)
+		if err != nil {
+			return err
+		}
+	} else {
+		tr, err := trie.NewZkTrie(common.BytesToHash(root), triedb)
+		if err != nil {
+			return err
+		}
+		nodes, err = tr.NodeIterator(nil)
+		if err != nil {
+			return err
+		}
+	}
+	out := ctx.String(zkNodesOutFlag.Name)
+	if err := os.MkdirAll(out, 0755); err != nil {
+		return err
+	}
+	fn := filepath.Join(out, fmt.Sprintf("zknodes-%x.rlp", root))
+	fh, err := os.OpenFile(fn, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	defer fh.Close()
+
+	var (
+		writer = bufio.NewWriter(fh)
+		count  int64
+		size   common.StorageSize
+		start  = time.Now()
+		logged = time.Now()
+	)
+	log.Info("Exporting zktrie nodes", "root", common.BytesToHash(root), "file", fn)
+	for nodes.Next(true) {
+		blob := nodes.NodeBlob()
+		if len(blob) == 0 {
+			continue // empty subtree
+		}
+		entry := &zkNodeEntry{
+			Path: common.CopyBytes(nodes.Path()),
+			Hash: nodes.Hash(),
+			Blob: blob,
+		}
+		if err := rlp.Encode(writer, entry); err != nil {
+			return err
+		}
+		count++
+		size += common.StorageSize(len(blob))
+
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Exporting zktrie nodes", "nodes", count, "size", size, "elapsed", common.PrettyDuration(time.Since(start)))
+			logged = time.Now()
+		}
+	}
+	if err := nodes.Error(); err != nil {
+		return err
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+	log.Info("Exported zktrie nodes", "file", fn, "nodes", count, "size", size, "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
+
+// prunePreimages deletes every preimage entry from the database.
+func prunePreimages(ctx *cli.Context) error {
+	var (
+		stack, _  = makeConfigNode(ctx)
+		interrupt = make(chan os.Signal, 1)
+	)
+	defer stack.Close()
+	signal.Notify(interrupt, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(interrupt)
+
+	db := utils.MakeChainDatabase(ctx, stack, false)
+	defer db.Close()
+
+	var (
+		it      = db.NewIterator(rawdb.PreimagePrefix, nil)
+		batch   = db.NewBatch()
+		count   int64
+		size    common.StorageSize
+		start   = time.Now()
+		logged  = time.Now()
+		aborted bool
+	)
+	log.Info("Pruning preimages")
+	for it.Next() {
+		key := it.Key()
+		if len(key) != len(rawdb.PreimagePrefix)+common.HashLength {
+			continue
+		}
+		batch.Delete(key)
+		count++
+		size += common.StorageSize(len(key) + len(it.Value()))
+
+		if batch.ValueSize() >= ethdb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				it.Release()
+				return err
+			}
+			batch.Reset()
+
+			select {
+			case <-interrupt:
+				aborted = true
+			default:
+			}
+			if aborted {
+				break
+			}
+			// Reopen the iterator to release the data pinned by the old one. The
+			// key is owned by the iterator, copy it before releasing.
+			next := common.CopyBytes(key[len(rawdb.PreimagePrefix):])
+			it.Release()
+			it = db.NewIterator(rawdb.PreimagePrefix, next)
+		}
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Pruning preimages", "count", count, "size", size, "elapsed", common.PrettyDuration(time.Since(start)))
+			logged = time.Now()
+		}
+	}
+	err := it.Error()
+	it.Release()
+	if err != nil {
+		return err
+	}
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	if aborted {
+		log.Warn("Preimage pruning interrupted, rerun to continue", "count", count, "size", size, "elapsed", common.PrettyDuration(time.Since(start)))
+		return nil
+	}
+	log.Info("Pruned preimages", "count", count, "size", size, "elapsed", common.PrettyDuration(time.Since(start)))
+
+	// Compact the deleted range to reclaim the disk space
+	end := common.CopyBytes(rawdb.PreimagePrefix)
+	end[len(end)-1]++
+
+	cstart := time.Now()
+	log.Info("Compacting database", "range", fmt.Sprintf("%#x-%#x", rawdb.PreimagePrefix, end))
+	if err := db.Compact(rawdb.PreimagePrefix, end); err != nil {
+		return err
+	}
+	log.Info("Compacted database", "elapsed", common.PrettyDuration(time.Since(cstart)))
+	return nil
+}
+
+// dbGuard installs or removes the migration guard of the database.
+func dbGuard(ctx *cli.Context) error {
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	db := utils.MakeChainDatabase(ctx, stack, false)
+	defer db.Close()
+
+	guard := rawdb.ReadMigrationGuard(db)
+	if ctx.Bool(removeGuardFlag.Name) {
+		if guard == nil {
+			log.Info("Database is not guarded")
+			return nil
+		}
+		rawdb.DeleteMigrationGuard(db)
+		log.Info("Removed migration guard", "version", *guard)
+		return nil
+	}
+	if guard != nil {
+		log.Info("Database is already guarded", "version", *guard)
+		return nil
+	}
+	version := rawdb.ReadDatabaseVersion(db)
+	if version == nil {
+		return errors.New("database version unknown, run geth once to initialize it")
+	}
+	rawdb.WriteMigrationGuard(db, *version)
+	log.Info("Installed migration guard", "version", *version)
+	return nil
+}
+
+// dbMigrationCleanup deletes the migration progress metadata.
+func dbMigrationCleanup(ctx *cli.Context) error {
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	db := utils.MakeChainDatabase(ctx, stack, false)
+	defer db.Close()
+
+	if rawdb.ReadMigrationReceipt(db) == nil {
+		confirm, err := prompt.Stdin.PromptConfirm("No migration receipt found, the migration may be incomplete. Remove its progress anyway?")
+		if err != nil {
+			return err
+		}
+		if !confirm {
+			log.Info("Migration cleanup skipped")
+			return nil
+		}
+	}
+	deleted, err := rawdb.DeleteMigrationProgress(db)
+	if err != nil {
+		return err
+	}
+	log.Info("Removed migration progress", "entries", deleted)
+	return nil
+}
+
 func showMetaData(ctx *cli.Context) error {
 	stack, _ := makeConfigNode(ctx)
 	defer stack.Close()
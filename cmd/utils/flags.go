@@ -52,6 +52,7 @@ import (
 	"github.com/ethereum/go-ethereum/eth/gasprice"
 	"github.com/ethereum/go-ethereum/eth/tracers"
 	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/ethdb/pebble"
 	"github.com/ethereum/go-ethereum/ethdb/remotedb"
 	"github.com/ethereum/go-ethereum/ethstats"
 	"github.com/ethereum/go-ethereum/graphql"
@@ -103,6 +104,36 @@ var (
 		Value:    node.DefaultConfig.DBEngine,
 		Category: flags.EthCategory,
 	}
+	DBPebbleMemTableFlag = &cli.Uint64Flag{
+		Name:     "db.pebble.memtable",
+		Usage:    "Size of a single pebble memory table in megabytes (default = derived from database cache)",
+		Category: flags.PerfCategory,
+	}
+	DBPebbleL0CompactionFlag = &cli.IntFlag{
+		Name:     "db.pebble.l0compaction",
+		Usage:    "Number of pebble level-zero files triggering a compaction (default = engine default)",
+		Category: flags.PerfCategory,
+	}
+	DBPebbleL0StopWritesFlag = &cli.IntFlag{
+		Name:     "db.pebble.l0stopwrites",
+		Usage:    "Number of pebble level-zero files stopping writes until compacted (default = engine default)",
+		Category: flags.PerfCategory,
+	}
+	DBPebbleCompactionsFlag = &cli.IntFlag{
+		Name:     "db.pebble.compactions",
+		Usage:    "Maximum number of concurrent pebble compactions (default = number of CPUs)",
+		Category: flags.PerfCategory,
+	}
+	DBPebbleNoWALFlag = &cli.BoolFlag{
+		Name:     "db.pebble.nowal",
+		Usage:    "Disable the pebble write-ahead log, losing unflushed writes on crash (only for bulk imports which can be redone)",
+		Category: flags.PerfCategory,
+	}
+	UnsafeNoSyncFlag = &cli.BoolFlag{
+		Name:     "unsafe.nosync",
+		Usage:    "Don't sync pebble database writes to disk individually, losing the most recent writes on power failure (only for bulk imports which can be redone)",
+		Category: flags.PerfCategory,
+	}
 	AncientFlag = &flags.DirectoryFlag{
 		Name:     "datadir.ancient",
 		Usage:    "Root directory for ancient data (default = inside chaindata)",
@@ -1036,6 +1067,12 @@ var (
 		AncientFlag,
 		RemoteDBFlag,
 		DBEngineFlag,
+		DBPebbleMemTableFlag,
+		DBPebbleL0CompactionFlag,
+		DBPebbleL0StopWritesFlag,
+		DBPebbleCompactionsFlag,
+		DBPebbleNoWALFlag,
+		UnsafeNoSyncFlag,
 		StateSchemeFlag,
 		HttpHeaderFlag,
 	}
@@ -1520,6 +1557,7 @@ func SetNodeConfig(ctx *cli.Context, cfg *node.Config) {
 		log.Info(fmt.Sprintf("Using %s as db engine", dbEngine))
 		cfg.DBEngine = dbEngine
 	}
+	setPebbleConfig(ctx, cfg)
 	// deprecation notice for log debug flags (TODO: find a more appropriate place to put these?)
 	if ctx.IsSet(LogBacktraceAtFlag.Name) {
 		log.Warn("log.backtrace flag is deprecated")
@@ -1529,6 +1567,42 @@ func SetNodeConfig(ctx *cli.Context, cfg *node.Config) {
 	}
 }
 
+// setPebbleConfig applies the pebble engine tuning flags to the node config.
+func setPebbleConfig(ctx *cli.Context, cfg *node.Config) {
+	if !ctx.IsSet(DBPebbleMemTableFlag.Name) && !ctx.IsSet(DBPebbleL0CompactionFlag.Name) &&
+		!ctx.IsSet(DBPebbleL0StopWritesFlag.Name) && !ctx.IsSet(DBPebbleCompactionsFlag.Name) && !ctx.IsSet(DBPebbleNoWALFlag.Name) &&
+		!ctx.IsSet(UnsafeNoSyncFlag.Name) {
+		return
+	}
+	if cfg.DBEngine == "leveldb" {
+		Fatalf("Pebble tuning flags are not supported with db.engine 'leveldb'")
+	}
+	if cfg.DBPebble == nil {
+		cfg.DBPebble = new(pebble.Config)
+	}
+	if ctx.IsSet(DBPebbleMemTableFlag.Name) {
+		cfg.DBPebble.MemTableSize = ctx.Uint64(DBPebbleMemTableFlag.Name) * 1024 * 1024
+	}
+	if ctx.IsSet(DBPebbleL0CompactionFlag.Name) {
+		cfg.DBPebble.L0CompactionThreshold = ctx.Int(DBPebbleL0CompactionFlag.Name)
+	}
+	if ctx.IsSet(DBPebbleL0StopWritesFlag.Name) {
+		cfg.DBPebble.L0StopWritesThreshold = ctx.Int(DBPebbleL0StopWritesFlag.Name)
+	}
+	if ctx.IsSet(DBPebbleCompactionsFlag.Name) {
+		cfg.DBPebble.MaxConcurrentCompactions = ctx.Int(DBPebbleCompactionsFlag.Name)
+	}
+	if ctx.IsSet(DBPebbleNoWALFlag.Name) {
+		cfg.DBPebble.DisableWAL = ctx.Bool(DBPebbleNoWALFlag.Name)
+	}
+	if ctx.IsSet(UnsafeNoSyncFlag.Name) {
+		cfg.DBPebble.NoSync = ctx.Bool(UnsafeNoSyncFlag.Name)
+	}
+	if err := cfg.DBPebble.Validate(); err != nil {
+		Fatalf("Invalid pebble tuning: %v", err)
+	}
+}
+
 func setSmartCard(ctx *cli.Context, cfg *node.Config) {
 	// Skip enabling smartcards if no path is set
 	path := ctx.String(SmartCardDaemonPathFlag.Name)
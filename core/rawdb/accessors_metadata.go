@@ -17,7 +17,10 @@
 package rawdb
 
 import (
+	"bytes"
 	"encoding/json"
+	"fmt"
+	"math"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -98,6 +101,165 @@ func WriteGenesisStateSpec(db ethdb.KeyValueWriter, blockhash common.Hash, data
 	}
 }
 
+// ReadMigrationRoot retrieves the zktrie state root of the ongoing migration,
+// or an empty hash if no migration was started.
+func ReadMigrationRoot(db ethdb.KeyValueReader) common.Hash {
+	data, _ := db.Get(migrationRootKey)
+	if len(data) != common.HashLength {
+		return common.Hash{}
+	}
+	return common.BytesToHash(data)
+}
+
+// WriteMigrationRoot stores the zktrie state root the migration converts.
+func WriteMigrationRoot(db ethdb.KeyValueWriter, root common.Hash) {
+	if err := db.Put(migrationRootKey, root[:]); err != nil {
+		log.Crit("Failed to store migration root", "err", err)
+	}
+}
+
+// DeleteMigrationRoot deletes the zktrie state root of the migration.
+func DeleteMigrationRoot(db ethdb.KeyValueWriter) {
+	if err := db.Delete(migrationRootKey); err != nil {
+		log.Crit("Failed to remove migration root", "err", err)
+	}
+}
+
+// MigrationReceipt describes a completed migration of the state from zktrie to
+// merkle patricia trie, letting later binaries and tools detect what happened
+// to the database.
+type MigrationReceipt struct {
+	ToolVersion string      `json:"toolVersion"` // Version of the tool which performed the migration
+	StartTime   uint64      `json:"startTime"`   // Unix timestamp the migration started at
+	EndTime     uint64      `json:"endTime"`     // Unix timestamp the migration finished at
+	ZkRoot      common.Hash `json:"zkRoot"`      // State root of the migrated zktrie
+	MptRoot     common.Hash `json:"mptRoot"`     // State root of the resulting merkle patricia trie
+	ReportHash  common.Hash `json:"reportHash"`  // Hash of the report produced by the migration
+}
+
+// String implements fmt.Stringer.
+func (r *MigrationReceipt) String() string {
+	return fmt.Sprintf("version=%s start=%s end=%s zkroot=%x mptroot=%x report=%x",
+		r.ToolVersion, time.Unix(int64(r.StartTime), 0).UTC().Format(time.RFC3339),
+		time.Unix(int64(r.EndTime), 0).UTC().Format(time.RFC3339), r.ZkRoot, r.MptRoot, r.ReportHash)
+}
+
+// ReadMigrationReceipt retrieves the receipt of the state migration performed
+// on the database, or nil if the database was never migrated.
+func ReadMigrationReceipt(db ethdb.KeyValueReader) *MigrationReceipt {
+	data, _ := db.Get(migrationReceiptKey)
+	if len(data) == 0 {
+		return nil
+	}
+	var receipt MigrationReceipt
+	if err := json.Unmarshal(data, &receipt); err != nil {
+		log.Error("Invalid migration receipt JSON", "err", err)
+		return nil
+	}
+	return &receipt
+}
+
+// WriteMigrationReceipt stores the receipt of the state migration.
+func WriteMigrationReceipt(db ethdb.KeyValueWriter, receipt *MigrationReceipt) {
+	data, err := json.Marshal(receipt)
+	if err != nil {
+		log.Crit("Failed to JSON encode migration receipt", "err", err)
+	}
+	if err := db.Put(migrationReceiptKey, data); err != nil {
+		log.Crit("Failed to store migration receipt", "err", err)
+	}
+}
+
+// DeleteMigrationReceipt deletes the receipt of the state migration.
+func DeleteMigrationReceipt(db ethdb.KeyValueWriter) {
+	if err := db.Delete(migrationReceiptKey); err != nil {
+		log.Crit("Failed to remove migration receipt", "err", err)
+	}
+}
+
+// HasIncompleteMigration reports whether a state migration was started on the
+// database, i.e. its root is stored, but never completed with a receipt.
+func HasIncompleteMigration(db ethdb.KeyValueReader) bool {
+	return ReadMigrationRoot(db) != (common.Hash{}) && ReadMigrationReceipt(db) == nil
+}
+
+// MigrationGuardVersion is the database version advertised while the migration
+// guard is in place. It is above any version a released binary supports, so
+// binaries unaware of the migration refuse to open the database instead of
+// corrupting it.
+const MigrationGuardVersion = uint64(math.MaxUint32)
+
+// ReadMigrationGuard retrieves the real version of a guarded database, or nil
+// if the database is not guarded.
+func ReadMigrationGuard(db ethdb.KeyValueReader) *uint64 {
+	var version uint64
+
+	enc, _ := db.Get(migrationGuardKey)
+	if len(enc) == 0 {
+		return nil
+	}
+	if err := rlp.DecodeBytes(enc, &version); err != nil {
+		return nil
+	}
+	return &version
+}
+
+// WriteMigrationGuard guards the database against binaries unaware of the
+// state migration, stashing the given real database version.
+func WriteMigrationGuard(db ethdb.KeyValueWriter, version uint64) {
+	enc, err := rlp.EncodeToBytes(version)
+	if err != nil {
+		log.Crit("Failed to encode migration guard", "err", err)
+	}
+	if err = db.Put(migrationGuardKey, enc); err != nil {
+		log.Crit("Failed to store the migration guard", "err", err)
+	}
+	WriteDatabaseVersion(db, MigrationGuardVersion)
+}
+
+// DeleteMigrationGuard removes the migration guard, restoring the real
+// database version. It is a noop if the database is not guarded.
+func DeleteMigrationGuard(db ethdb.KeyValueStore) {
+	version := ReadMigrationGuard(db)
+	if version == nil {
+		return
+	}
+	WriteDatabaseVersion(db, *version)
+	if err := db.Delete(migrationGuardKey); err != nil {
+		log.Crit("Failed to remove the migration guard", "err", err)
+	}
+}
+
+// DeleteMigrationProgress removes the metadata the migrator keeps while the
+// migration is in progress, returning the number of deleted entries. The
+// receipt and the guard are kept, as they outlive the migration itself.
+func DeleteMigrationProgress(db ethdb.KeyValueStore) (int, error) {
+	it := db.NewIterator(migrationPrefix, nil)
+	defer it.Release()
+
+	var (
+		batch   = db.NewBatch()
+		deleted int
+	)
+	for it.Next() {
+		key := it.Key()
+		if bytes.Equal(key, migrationReceiptKey) || bytes.Equal(key, migrationGuardKey) {
+			continue
+		}
+		if err := batch.Delete(key); err != nil {
+			return 0, err
+		}
+		deleted++
+	}
+	if err := it.Error(); err != nil {
+		return 0, err
+	}
+	if err := batch.Write(); err != nil {
+		return 0, err
+	}
+	return deleted, nil
+}
+
 // crashList is a list of unclean-shutdown-markers, for rlp-encoding to the
 // database
 type crashList struct {
@@ -0,0 +1,165 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Tests migration root storage and retrieval operations.
+func TestMigrationRootStorage(t *testing.T) {
+	db := NewMemoryDatabase()
+
+	if root := ReadMigrationRoot(db); root != (common.Hash{}) {
+		t.Fatalf("Non existent migration root returned: %x", root)
+	}
+	root := common.HexToHash("0x1234")
+	WriteMigrationRoot(db, root)
+	if have := ReadMigrationRoot(db); have != root {
+		t.Fatalf("Migration root mismatch: have %x, want %x", have, root)
+	}
+	DeleteMigrationRoot(db)
+	if root := ReadMigrationRoot(db); root != (common.Hash{}) {
+		t.Fatalf("Deleted migration root returned: %x", root)
+	}
+	// Malformed roots should be reported as missing
+	db.Put(migrationRootKey, []byte{0x01, 0x02})
+	if root := ReadMigrationRoot(db); root != (common.Hash{}) {
+		t.Fatalf("Malformed migration root returned: %x", root)
+	}
+}
+
+// Tests migration receipt storage and retrieval operations.
+func TestMigrationReceiptStorage(t *testing.T) {
+	db := NewMemoryDatabase()
+
+	if receipt := ReadMigrationReceipt(db); receipt != nil {
+		t.Fatalf("Non existent migration receipt returned: %v", receipt)
+	}
+	receipt := &MigrationReceipt{
+		ToolVersion: "1.0.0",
+		StartTime:   1700000000,
+		EndTime:     1700003600,
+		ZkRoot:      common.Hash{0x01},
+		MptRoot:     common.Hash{0x02},
+		ReportHash:  common.Hash{0x03},
+	}
+	WriteMigrationReceipt(db, receipt)
+	if have := ReadMigrationReceipt(db); !reflect.DeepEqual(have, receipt) {
+		t.Fatalf("Migration receipt mismatch: have %v, want %v", have, receipt)
+	}
+	DeleteMigrationReceipt(db)
+	if receipt := ReadMigrationReceipt(db); receipt != nil {
+		t.Fatalf("Deleted migration receipt returned: %v", receipt)
+	}
+	// Corrupted receipts should be reported as missing
+	db.Put(migrationReceiptKey, []byte("not json"))
+	if receipt := ReadMigrationReceipt(db); receipt != nil {
+		t.Fatalf("Corrupted migration receipt returned: %v", receipt)
+	}
+}
+
+// Tests that a migration is only reported incomplete if its root was stored
+// without a receipt.
+func TestHasIncompleteMigration(t *testing.T) {
+	tests := []struct {
+		root       bool
+		receipt    bool
+		incomplete bool
+	}{
+		{false, false, false}, // never migrated
+		{true, false, true},   // migration started, not finished
+		{false, true, false},  // migration finished, progress cleaned up
+		{true, true, false},   // migration finished, progress kept
+	}
+	for i, tt := range tests {
+		db := NewMemoryDatabase()
+		if tt.root {
+			WriteMigrationRoot(db, common.Hash{0x01})
+		}
+		if tt.receipt {
+			WriteMigrationReceipt(db, &MigrationReceipt{ToolVersion: "1.0.0"})
+		}
+		if have := HasIncompleteMigration(db); have != tt.incomplete {
+			t.Errorf("test %d: incomplete migration mismatch: have %v, want %v", i, have, tt.incomplete)
+		}
+	}
+}
+
+// Tests that the migration guard hides the real database version and that
+// removing it restores the original one.
+func TestMigrationGuard(t *testing.T) {
+	db := NewMemoryDatabase()
+	WriteDatabaseVersion(db, 8)
+
+	if guard := ReadMigrationGuard(db); guard != nil {
+		t.Fatalf("Non existent migration guard returned: %d", *guard)
+	}
+	WriteMigrationGuard(db, 8)
+	if version := ReadDatabaseVersion(db); version == nil || *version != MigrationGuardVersion {
+		t.Fatalf("Guarded database version mismatch: have %v, want %d", version, MigrationGuardVersion)
+	}
+	if guard := ReadMigrationGuard(db); guard == nil || *guard != 8 {
+		t.Fatalf("Migration guard mismatch: have %v, want 8", guard)
+	}
+	DeleteMigrationGuard(db)
+	if guard := ReadMigrationGuard(db); guard != nil {
+		t.Fatalf("Deleted migration guard returned: %d", *guard)
+	}
+	if version := ReadDatabaseVersion(db); version == nil || *version != 8 {
+		t.Fatalf("Restored database version mismatch: have %v, want 8", version)
+	}
+	// Removing a non-existent guard should leave the version alone
+	DeleteMigrationGuard(db)
+	if version := ReadDatabaseVersion(db); version == nil || *version != 8 {
+		t.Fatalf("Database version changed by noop removal: have %v, want 8", version)
+	}
+}
+
+// Tests that cleaning up the migration progress removes every migration key but
+// the receipt and the guard, leaving the rest of the database untouched.
+func TestDeleteMigrationProgress(t *testing.T) {
+	db := NewMemoryDatabase()
+
+	WriteDatabaseVersion(db, 8)
+	WriteMigrationGuard(db, 8)
+	WriteMigrationReceipt(db, &MigrationReceipt{ToolVersion: "1.0.0"})
+	WriteMigrationRoot(db, common.Hash{0x01})
+	db.Put(migrationKey("progress"), []byte{0x02})
+	db.Put([]byte("migrations"), []byte{0x03})
+
+	deleted, err := DeleteMigrationProgress(db)
+	if err != nil {
+		t.Fatalf("Failed to delete migration progress: %v", err)
+	}
+	if deleted != 2 {
+		t.Fatalf("Deleted entry count mismatch: have %d, want 2", deleted)
+	}
+	for _, key := range [][]byte{migrationRootKey, migrationKey("progress")} {
+		if ok, _ := db.Has(key); ok {
+			t.Errorf("Migration progress %q not deleted", key)
+		}
+	}
+	for _, key := range [][]byte{migrationReceiptKey, migrationGuardKey, databaseVersionKey, []byte("migrations")} {
+		if ok, _ := db.Has(key); !ok {
+			t.Errorf("Unrelated key %q deleted", key)
+		}
+	}
+}
@@ -325,7 +325,13 @@ func NewLevelDBDatabase(file string, cache int, handles int, namespace string, r
 // NewPebbleDBDatabase creates a persistent key-value database without a freezer
 // moving immutable chain segments into cold storage.
 func NewPebbleDBDatabase(file string, cache int, handles int, namespace string, readonly, ephemeral bool) (ethdb.Database, error) {
-	db, err := pebble.New(file, cache, handles, namespace, readonly, ephemeral)
+	return NewPebbleDBDatabaseWithConfig(file, cache, handles, namespace, readonly, ephemeral, nil)
+}
+
+// NewPebbleDBDatabaseWithConfig is the same as NewPebbleDBDatabase, but it also
+// applies the given pebble engine tuning, which may be nil.
+func NewPebbleDBDatabaseWithConfig(file string, cache int, handles int, namespace string, readonly, ephemeral bool, config *pebble.Config) (ethdb.Database, error) {
+	db, err := pebble.NewWithConfig(file, cache, handles, namespace, readonly, ephemeral, config)
 	if err != nil {
 		return nil, err
 	}
@@ -366,6 +372,9 @@ type OpenOptions struct {
 	// Ephemeral means that filesystem sync operations should be avoided: data integrity in the face of
 	// a crash is not important. This option should typically be used in tests.
 	Ephemeral bool
+	// Pebble contains the optional engine tuning applied if the database is
+	// backed by pebble.
+	Pebble *pebble.Config
 }
 
 // openKeyValueDatabase opens a disk-based key-value database, e.g. leveldb or pebble.
@@ -387,15 +396,18 @@ func openKeyValueDatabase(o OpenOptions) (ethdb.Database, error) {
 	}
 	if o.Type == dbPebble || existingDb == dbPebble {
 		log.Info("Using pebble as the backing database")
-		return NewPebbleDBDatabase(o.Directory, o.Cache, o.Handles, o.Namespace, o.ReadOnly, o.Ephemeral)
+		return NewPebbleDBDatabaseWithConfig(o.Directory, o.Cache, o.Handles, o.Namespace, o.ReadOnly, o.Ephemeral, o.Pebble)
 	}
 	if o.Type == dbLeveldb || existingDb == dbLeveldb {
 		log.Info("Using leveldb as the backing database")
+		if o.Pebble != nil {
+			log.Warn("Ignoring pebble tuning for leveldb database", "directory", o.Directory)
+		}
 		return NewLevelDBDatabase(o.Directory, o.Cache, o.Handles, o.Namespace, o.ReadOnly)
 	}
 	// No pre-existing database, no user-requested one either. Default to Pebble.
 	log.Info("Defaulting to pebble as the backing database")
-	return NewPebbleDBDatabase(o.Directory, o.Cache, o.Handles, o.Namespace, o.ReadOnly, o.Ephemeral)
+	return NewPebbleDBDatabaseWithConfig(o.Directory, o.Cache, o.Handles, o.Namespace, o.ReadOnly, o.Ephemeral, o.Pebble)
 }
 
 // Open opens both a disk-based key-value database such as leveldb or pebble, but also
@@ -533,6 +545,8 @@ func InspectDatabase(db ethdb.Database, keyPrefix, keyStart []byte, isZk bool) e
 			metadata.Add(size)
 		case bytes.HasPrefix(key, genesisPrefix) && len(key) == (len(genesisPrefix)+common.HashLength):
 			metadata.Add(size)
+		case bytes.HasPrefix(key, migrationPrefix):
+			metadata.Add(size)
 		case bytes.HasPrefix(key, bloomBitsPrefix) && len(key) == (len(bloomBitsPrefix)+10+common.HashLength):
 			bloomBits.Add(size)
 		case bytes.HasPrefix(key, BloomBitsIndexPrefix):
@@ -647,6 +661,7 @@ func ReadChainMetadata(db ethdb.KeyValueStore) [][]string {
 	}
 	data := [][]string{
 		{"databaseVersion", pp(ReadDatabaseVersion(db))},
+		{"migrationGuard", pp(ReadMigrationGuard(db))},
 		{"headBlockHash", fmt.Sprintf("%v", ReadHeadBlockHash(db))},
 		{"headFastBlockHash", fmt.Sprintf("%v", ReadHeadFastBlockHash(db))},
 		{"headHeaderHash", fmt.Sprintf("%v", ReadHeadHeaderHash(db))},
@@ -662,5 +677,11 @@ func ReadChainMetadata(db ethdb.KeyValueStore) [][]string {
 	if b := ReadSkeletonSyncStatus(db); b != nil {
 		data = append(data, []string{"SkeletonSyncStatus", string(b)})
 	}
+	if root := ReadMigrationRoot(db); root != (common.Hash{}) {
+		data = append(data, []string{"migrationRoot", fmt.Sprintf("%v", root)})
+	}
+	if r := ReadMigrationReceipt(db); r != nil {
+		data = append(data, []string{"migrationReceipt", r.String()})
+	}
 	return data
 }
@@ -119,6 +119,13 @@ var (
 	configPrefix   = []byte("ethereum-config-")  // config prefix for the db
 	genesisPrefix  = []byte("ethereum-genesis-") // genesis state prefix for the db
 
+	// Metadata of the zktrie to MPT state migration, kept apart from the rest of
+	// the keyspace so it can be found and cleaned up as a whole.
+	migrationPrefix     = []byte("migration-")    // migrationPrefix + name -> migration metadata
+	migrationRootKey    = migrationKey("root")    // zktrie state root the ongoing migration converts
+	migrationReceiptKey = migrationKey("receipt") // receipt of the completed migration
+	migrationGuardKey   = migrationKey("guard")   // real database version while guarded against pre-migration binaries
+
 	// BloomBitsIndexPrefix is the data table of a chain indexer to track its progress
 	BloomBitsIndexPrefix = []byte("iB")
 
@@ -253,6 +260,11 @@ func configKey(hash common.Hash) []byte {
 	return append(configPrefix, hash.Bytes()...)
 }
 
+// migrationKey = migrationPrefix + name
+func migrationKey(name string) []byte {
+	return append(migrationPrefix, name...)
+}
+
 // genesisStateSpecKey = genesisPrefix + hash
 func genesisStateSpecKey(hash common.Hash) []byte {
 	return append(genesisPrefix, hash.Bytes()...)
@@ -152,6 +152,13 @@ func (t *table) Stat(property string) (string, error) {
 	return t.db.Stat(property)
 }
 
+// SyncKeyValue ensures that all pending writes are flushed to disk, guaranteeing
+// data durability up to that point. It syncs the whole underlying database, not
+// only the table.
+func (t *table) SyncKeyValue() error {
+	return t.db.SyncKeyValue()
+}
+
 // Compact flattens the underlying data store for the given key range. In essence,
 // deleted and overwritten versions are discarded, and the data is rearranged to
 // reduce the cost of operations needed to access them.
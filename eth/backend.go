@@ -140,6 +140,11 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 	if err != nil {
 		return nil, err
 	}
+	// Refuse to extend a chain whose state migration was started but never
+	// completed, the state may be half converted.
+	if rawdb.HasIncompleteMigration(chainDb) {
+		return nil, fmt.Errorf("database contains an incomplete state migration of root %x, resume the migration or discard it with 'geth db migration-cleanup'", rawdb.ReadMigrationRoot(chainDb))
+	}
 	scheme, err := rawdb.ParseStateScheme(config.StateScheme, chainDb, config.Genesis != nil && config.Genesis.Config != nil && config.Genesis.Config.Zktrie)
 	if err != nil {
 		return nil, err
@@ -181,6 +186,14 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 		nodeCloser:        stack.Close,
 	}
 	bcVersion := rawdb.ReadDatabaseVersion(chainDb)
+
+	// If the database is guarded against binaries unaware of the state migration,
+	// the real version is stashed in the guard.
+	guarded := rawdb.ReadMigrationGuard(chainDb)
+	if guarded != nil {
+		log.Info("Database is guarded against pre-migration binaries", "version", *guarded)
+		bcVersion = guarded
+	}
 	dbVer := "<nil>"
 	if bcVersion != nil {
 		dbVer = fmt.Sprintf("%d", *bcVersion)
@@ -193,7 +206,11 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 			if bcVersion != nil { // only print warning on upgrade, not on init
 				log.Warn("Upgrade blockchain database version", "from", dbVer, "to", core.BlockChainVersion)
 			}
-			rawdb.WriteDatabaseVersion(chainDb, core.BlockChainVersion)
+			if guarded != nil {
+				rawdb.WriteMigrationGuard(chainDb, core.BlockChainVersion)
+			} else {
+				rawdb.WriteDatabaseVersion(chainDb, core.BlockChainVersion)
+			}
 		}
 	}
 	var (
@@ -55,12 +55,20 @@ type Compacter interface {
 	Compact(start []byte, limit []byte) error
 }
 
+// KeyValueSyncer wraps the SyncKeyValue method of a backing data store.
+type KeyValueSyncer interface {
+	// SyncKeyValue ensures that all pending writes (potentially buffered in
+	// memory or written without syncing) are durably persisted to disk.
+	SyncKeyValue() error
+}
+
 // KeyValueStore contains all the methods required to allow handling different
 // key-value data stores backing the high level database.
 type KeyValueStore interface {
 	KeyValueReader
 	KeyValueWriter
 	KeyValueStater
+	KeyValueSyncer
 	Batcher
 	Iteratee
 	Compacter
@@ -183,6 +191,7 @@ type AncientStore interface {
 type Database interface {
 	Reader
 	Writer
+	KeyValueSyncer
 	Batcher
 	Iteratee
 	Stater
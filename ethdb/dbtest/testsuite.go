@@ -385,6 +385,9 @@ func TestDatabaseSuite(t *testing.T, New func() ethdb.KeyValueStore) {
 	t.Run("OperatonsAfterClose", func(t *testing.T) {
 		db := New()
 		db.Put([]byte("key"), []byte("value"))
+		if err := db.SyncKeyValue(); err != nil {
+			t.Fatalf("expected no error on SyncKeyValue before Close, got %v", err)
+		}
 		db.Close()
 		if _, err := db.Get([]byte("key")); err == nil {
 			t.Fatalf("expected error on Get after Close")
@@ -398,6 +401,9 @@ func TestDatabaseSuite(t *testing.T, New func() ethdb.KeyValueStore) {
 		if err := db.Delete([]byte("key")); err == nil {
 			t.Fatalf("expected error on Delete after Close")
 		}
+		if err := db.SyncKeyValue(); err == nil {
+			t.Fatalf("expected error on SyncKeyValue after Close")
+		}
 
 		b := db.NewBatch()
 		if err := b.Put([]byte("batchkey"), []byte("batchval")); err != nil {
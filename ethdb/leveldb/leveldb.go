@@ -55,6 +55,10 @@ const (
 	metricsGatheringInterval = 3 * time.Second
 )
 
+// syncMarkerKey is a reserved key written and immediately deleted to force a
+// sync of the write-ahead-log, it never persists in the database.
+var syncMarkerKey = []byte("leveldb-sync-marker")
+
 // Database is a persistent key-value store. Apart from basic data storage
 // functionality it also supports batch writes and iterating over the keyspace in
 // binary-alphabetical order.
@@ -70,6 +74,7 @@ type Database struct {
 	diskSizeGauge       metrics.Gauge // Gauge for tracking the size of all the levels in the database
 	diskReadMeter       metrics.Meter // Meter for measuring the effective amount of data read
 	diskWriteMeter      metrics.Meter // Meter for measuring the effective amount of data written
+	logicalWriteMeter   metrics.Meter // Meter for measuring the amount of data written by the user, for tracking write amplification
 	memCompGauge        metrics.Gauge // Gauge for tracking the number of memory compaction
 	level0CompGauge     metrics.Gauge // Gauge for tracking the number of table compaction in level0
 	nonlevel0CompGauge  metrics.Gauge // Gauge for tracking the number of table compaction in non0 level
@@ -139,6 +144,7 @@ func NewCustom(file string, namespace string, customize func(options *opt.Option
 	ldb.diskSizeGauge = metrics.NewRegisteredGauge(namespace+"disk/size", nil)
 	ldb.diskReadMeter = metrics.NewRegisteredMeter(namespace+"disk/read", nil)
 	ldb.diskWriteMeter = metrics.NewRegisteredMeter(namespace+"disk/write", nil)
+	ldb.logicalWriteMeter = metrics.NewRegisteredMeter(namespace+"disk/logical", nil)
 	ldb.writeDelayMeter = metrics.NewRegisteredMeter(namespace+"compact/writedelay/duration", nil)
 	ldb.writeDelayNMeter = metrics.NewRegisteredMeter(namespace+"compact/writedelay/counter", nil)
 	ldb.memCompGauge = metrics.NewRegisteredGauge(namespace+"compact/memory", nil)
@@ -199,28 +205,45 @@ func (db *Database) Get(key []byte) ([]byte, error) {
 
 // Put inserts the given value into the key-value store.
 func (db *Database) Put(key []byte, value []byte) error {
-	return db.db.Put(key, value, nil)
+	if err := db.db.Put(key, value, nil); err != nil {
+		return err
+	}
+	db.markLogicalWrite(len(key) + len(value))
+	return nil
 }
 
 // Delete removes the key from the key-value store.
 func (db *Database) Delete(key []byte) error {
-	return db.db.Delete(key, nil)
+	if err := db.db.Delete(key, nil); err != nil {
+		return err
+	}
+	db.markLogicalWrite(len(key))
+	return nil
+}
+
+// markLogicalWrite accounts the given amount of user data as written.
+func (db *Database) markLogicalWrite(size int) {
+	if db.logicalWriteMeter != nil {
+		db.logicalWriteMeter.Mark(int64(size))
+	}
 }
 
 // NewBatch creates a write-only key-value store that buffers changes to its host
 // database until a final write is called.
 func (db *Database) NewBatch() ethdb.Batch {
 	return &batch{
-		db: db.db,
-		b:  new(leveldb.Batch),
+		db:    db.db,
+		b:     new(leveldb.Batch),
+		meter: db.logicalWriteMeter,
 	}
 }
 
 // NewBatchWithSize creates a write-only database batch with pre-allocated buffer.
 func (db *Database) NewBatchWithSize(size int) ethdb.Batch {
 	return &batch{
-		db: db.db,
-		b:  leveldb.MakeBatch(size),
+		db:    db.db,
+		b:     leveldb.MakeBatch(size),
+		meter: db.logicalWriteMeter,
 	}
 }
 
@@ -265,6 +288,19 @@ func (db *Database) Compact(start []byte, limit []byte) error {
 	return db.db.CompactRange(util.Range{Start: start, Limit: limit})
 }
 
+// SyncKeyValue flushes all pending writes in the write-ahead-log to disk,
+// ensuring data durability up to that point.
+func (db *Database) SyncKeyValue() error {
+	// In theory, the WAL (Write-Ahead Log) can be explicitly synchronized using
+	// a write operation with SYNC=true. However, there is no dedicated method
+	// for this and goleveldb skips empty batches altogether, so a reserved key
+	// is written and deleted again in a single batch with the sync flag set.
+	batch := new(leveldb.Batch)
+	batch.Put(syncMarkerKey, nil)
+	batch.Delete(syncMarkerKey)
+	return db.db.Write(batch, &opt.WriteOptions{Sync: true})
+}
+
 // Path returns the path to the database directory.
 func (db *Database) Path() string {
 	return db.fn
@@ -388,9 +424,10 @@ func (db *Database) meter(refresh time.Duration, namespace string) {
 // batch is a write-only leveldb batch that commits changes to its host database
 // when Write is called. A batch cannot be used concurrently.
 type batch struct {
-	db   *leveldb.DB
-	b    *leveldb.Batch
-	size int
+	db    *leveldb.DB
+	b     *leveldb.Batch
+	size  int
+	meter metrics.Meter // Meter for measuring the amount of data written by the user
 }
 
 // Put inserts the given value into the batch for later committing.
@@ -414,7 +451,13 @@ func (b *batch) ValueSize() int {
 
 // Write flushes any accumulated data to disk.
 func (b *batch) Write() error {
-	return b.db.Write(b.b, nil)
+	if err := b.db.Write(b.b, nil); err != nil {
+		return err
+	}
+	if b.meter != nil {
+		b.meter.Mark(int64(b.size))
+	}
+	return nil
 }
 
 // Reset resets the batch for reuse.
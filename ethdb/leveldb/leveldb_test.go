@@ -17,6 +17,7 @@
 package leveldb
 
 import (
+	"sync/atomic"
 	"testing"
 
 	"github.com/ethereum/go-ethereum/ethdb"
@@ -39,6 +40,57 @@ func TestLevelDB(t *testing.T) {
 	})
 }
 
+// syncCountingStorage wraps a storage, counting the syncs of the files created
+// through it.
+type syncCountingStorage struct {
+	storage.Storage
+	syncs atomic.Int64
+}
+
+func (s *syncCountingStorage) Create(fd storage.FileDesc) (storage.Writer, error) {
+	w, err := s.Storage.Create(fd)
+	if err != nil {
+		return nil, err
+	}
+	return &syncCountingWriter{Writer: w, syncs: &s.syncs}, nil
+}
+
+type syncCountingWriter struct {
+	storage.Writer
+	syncs *atomic.Int64
+}
+
+func (w *syncCountingWriter) Sync() error {
+	w.syncs.Add(1)
+	return w.Writer.Sync()
+}
+
+// Tests that SyncKeyValue actually syncs the write-ahead-log to disk and leaves
+// no trace in the key space.
+func TestSyncKeyValue(t *testing.T) {
+	store := &syncCountingStorage{Storage: storage.NewMemStorage()}
+	ldb, err := leveldb.Open(store, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := &Database{db: ldb}
+	defer db.Close()
+
+	if err := db.Put([]byte("key"), []byte("value")); err != nil {
+		t.Fatal(err)
+	}
+	before := store.syncs.Load()
+	if err := db.SyncKeyValue(); err != nil {
+		t.Fatalf("Failed to sync: %v", err)
+	}
+	if store.syncs.Load() == before {
+		t.Fatal("SyncKeyValue did not sync the write-ahead-log")
+	}
+	if ok, _ := db.Has(syncMarkerKey); ok {
+		t.Fatal("sync marker left in the database")
+	}
+}
+
 func BenchmarkLevelDB(b *testing.B) {
 	dbtest.BenchDatabaseSuite(b, func() ethdb.KeyValueStore {
 		db, err := leveldb.Open(storage.NewMemStorage(), nil)
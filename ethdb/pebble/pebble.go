@@ -19,7 +19,9 @@ package pebble
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
+	"math"
 	"runtime"
 	"sync"
 	"sync/atomic"
@@ -47,6 +49,65 @@ const (
 	metricsGatheringInterval = 3 * time.Second
 )
 
+// Config contains the optional engine tuning knobs of the database. Any zero
+// field leaves the corresponding default in place.
+type Config struct {
+	MemTableSize             uint64 `toml:",omitempty"` // Size of a single memory table in bytes (default = derived from cache)
+	L0CompactionThreshold    int    `toml:",omitempty"` // Number of level-zero files triggering a compaction
+	L0StopWritesThreshold    int    `toml:",omitempty"` // Number of level-zero files stopping writes until compacted
+	MaxConcurrentCompactions int    `toml:",omitempty"` // Maximum number of concurrent compactions (default = number of CPUs)
+
+	// DisableWAL turns off the write-ahead log. Writes not yet flushed out of
+	// the memory tables are lost on crash, they are flushed explicitly when the
+	// database is closed. This is only meant for bulk imports which can be
+	// redone from scratch if interrupted.
+	DisableWAL bool `toml:",omitempty"`
+
+	// NoSync skips syncing the write-ahead log on every write, leaving it to
+	// the operating system. Writes survive a process crash, but the most recent
+	// ones are lost on power failure unless followed by an explicit sync, which
+	// is also done when the database is closed.
+	NoSync bool `toml:",omitempty"`
+}
+
+// Validate checks the tuning for values pebble would reject or which would stall
+// writes, taking into account the engine defaults of the fields not set.
+func (c *Config) Validate() error {
+	if c.L0CompactionThreshold < 0 || c.L0StopWritesThreshold < 0 || c.MaxConcurrentCompactions < 0 {
+		return errors.New("negative pebble tuning value")
+	}
+	opt := new(pebble.Options).EnsureDefaults()
+	c.apply(opt, math.MaxInt)
+	if opt.L0StopWritesThreshold < opt.L0CompactionThreshold {
+		return fmt.Errorf("level-zero stop writes threshold %d below compaction threshold %d", opt.L0StopWritesThreshold, opt.L0CompactionThreshold)
+	}
+	return nil
+}
+
+// apply overrides the given options with the explicitly set tuning values. The
+// memory table size is capped below the given maximum.
+func (c *Config) apply(opt *pebble.Options, maxMemTableSize int) {
+	if c.MemTableSize != 0 {
+		opt.MemTableSize = c.MemTableSize
+		if opt.MemTableSize >= uint64(maxMemTableSize) {
+			opt.MemTableSize = uint64(maxMemTableSize - 1)
+		}
+	}
+	if c.L0CompactionThreshold != 0 {
+		opt.L0CompactionThreshold = c.L0CompactionThreshold
+	}
+	if c.L0StopWritesThreshold != 0 {
+		opt.L0StopWritesThreshold = c.L0StopWritesThreshold
+	}
+	if c.MaxConcurrentCompactions != 0 {
+		compactions := c.MaxConcurrentCompactions
+		opt.MaxConcurrentCompactions = func() int { return compactions }
+	}
+	if c.DisableWAL {
+		opt.DisableWAL = true
+	}
+}
+
 // Database is a persistent key-value store based on the pebble storage engine.
 // Apart from basic data storage functionality it also supports batch writes and
 // iterating over the keyspace in binary-alphabetical order.
@@ -62,6 +123,7 @@ type Database struct {
 	diskSizeGauge       metrics.Gauge // Gauge for tracking the size of all the levels in the database
 	diskReadMeter       metrics.Meter // Meter for measuring the effective amount of data read
 	diskWriteMeter      metrics.Meter // Meter for measuring the effective amount of data written
+	logicalWriteMeter   metrics.Meter // Meter for measuring the amount of data written by the user, for tracking write amplification
 	memCompGauge        metrics.Gauge // Gauge for tracking the number of memory compaction
 	level0CompGauge     metrics.Gauge // Gauge for tracking the number of table compaction in level0
 	nonlevel0CompGauge  metrics.Gauge // Gauge for tracking the number of table compaction in non0 level
@@ -86,6 +148,7 @@ type Database struct {
 	writeDelayTime      atomic.Int64  // Total time spent in write stalls
 
 	writeOptions *pebble.WriteOptions
+	disableWAL   bool // Whether the memory tables need to be flushed explicitly to persist writes
 }
 
 func (d *Database) onCompactionBegin(info pebble.CompactionInfo) {
@@ -136,6 +199,16 @@ func (l panicLogger) Fatalf(format string, args ...interface{}) {
 // New returns a wrapped pebble DB object. The namespace is the prefix that the
 // metrics reporting should use for surfacing internal stats.
 func New(file string, cache int, handles int, namespace string, readonly bool, ephemeral bool) (*Database, error) {
+	return NewWithConfig(file, cache, handles, namespace, readonly, ephemeral, nil)
+}
+
+// NewWithConfig returns a wrapped pebble DB object tuned with the given config,
+// which may be nil. The namespace is the prefix that the metrics reporting should
+// use for surfacing internal stats.
+func NewWithConfig(file string, cache int, handles int, namespace string, readonly bool, ephemeral bool, config *Config) (*Database, error) {
+	if config == nil {
+		config = new(Config)
+	}
 	// Ensure we have some minimal caching and file guarantees
 	if cache < minCache {
 		cache = minCache
@@ -176,7 +249,8 @@ func New(file string, cache int, handles int, namespace string, readonly bool, e
 		fn:           file,
 		log:          logger,
 		quitChan:     make(chan chan error),
-		writeOptions: &pebble.WriteOptions{Sync: !ephemeral},
+		writeOptions: &pebble.WriteOptions{Sync: !ephemeral && !config.DisableWAL && !config.NoSync},
+		disableWAL:   config.DisableWAL && !readonly,
 	}
 	opt := &pebble.Options{
 		// Pebble has a single combined cache area and the write
@@ -224,6 +298,17 @@ func New(file string, cache int, handles int, namespace string, readonly bool, e
 	// for more details.
 	opt.Experimental.ReadSamplingMultiplier = -1
 
+	// Apply the explicitly requested engine tuning
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	config.apply(opt, maxMemTableSize)
+	if config.DisableWAL {
+		logger.Warn("Write-ahead log disabled, unflushed writes will be lost on crash")
+	} else if config.NoSync && !ephemeral {
+		logger.Warn("Synchronous writes disabled, unsynced writes will be lost on power failure")
+	}
+
 	// Open the db and recover any potential corruptions
 	innerDB, err := pebble.Open(file, opt)
 	if err != nil {
@@ -237,6 +322,7 @@ func New(file string, cache int, handles int, namespace string, readonly bool, e
 	db.diskSizeGauge = metrics.NewRegisteredGauge(namespace+"disk/size", nil)
 	db.diskReadMeter = metrics.NewRegisteredMeter(namespace+"disk/read", nil)
 	db.diskWriteMeter = metrics.NewRegisteredMeter(namespace+"disk/write", nil)
+	db.logicalWriteMeter = metrics.NewRegisteredMeter(namespace+"disk/logical", nil)
 	db.writeDelayMeter = metrics.NewRegisteredMeter(namespace+"compact/writedelay/duration", nil)
 	db.writeDelayNMeter = metrics.NewRegisteredMeter(namespace+"compact/writedelay/counter", nil)
 	db.memCompGauge = metrics.NewRegisteredGauge(namespace+"compact/memory", nil)
@@ -268,6 +354,14 @@ func (d *Database) Close() error {
 		}
 		d.quitChan = nil
 	}
+	// Without the write-ahead log, the content of the memory tables is only
+	// persisted by flushing them out explicitly. Refuse to silently drop it.
+	if d.disableWAL {
+		if err := d.db.Flush(); err != nil {
+			d.log.Error("Failed to flush memory tables", "err", err)
+			return errors.Join(err, d.db.Close())
+		}
+	}
 	return d.db.Close()
 }
 
@@ -312,7 +406,11 @@ func (d *Database) Put(key []byte, value []byte) error {
 	if d.closed {
 		return pebble.ErrClosed
 	}
-	return d.db.Set(key, value, d.writeOptions)
+	if err := d.db.Set(key, value, d.writeOptions); err != nil {
+		return err
+	}
+	d.markLogicalWrite(len(key) + len(value))
+	return nil
 }
 
 // Delete removes the key from the key-value store.
@@ -322,7 +420,18 @@ func (d *Database) Delete(key []byte) error {
 	if d.closed {
 		return pebble.ErrClosed
 	}
-	return d.db.Delete(key, nil)
+	if err := d.db.Delete(key, nil); err != nil {
+		return err
+	}
+	d.markLogicalWrite(len(key))
+	return nil
+}
+
+// markLogicalWrite accounts the given amount of user data as written.
+func (d *Database) markLogicalWrite(size int) {
+	if d.logicalWriteMeter != nil {
+		d.logicalWriteMeter.Mark(int64(size))
+	}
 }
 
 // NewBatch creates a write-only key-value store that buffers changes to its host
@@ -436,6 +545,24 @@ func (d *Database) Compact(start []byte, limit []byte) error {
 	return d.db.Compact(start, limit, true) // Parallelization is preferred
 }
 
+// SyncKeyValue flushes all pending writes to disk, ensuring data durability up
+// to that point. If the write-ahead log is disabled, the memory tables are
+// flushed into sstables, otherwise the log itself is synced.
+func (d *Database) SyncKeyValue() error {
+	d.quitLock.RLock()
+	defer d.quitLock.RUnlock()
+	if d.closed {
+		return pebble.ErrClosed
+	}
+	if d.disableWAL {
+		return d.db.Flush()
+	}
+	// The entry (value=nil) is not written to the database; it is only added
+	// to the WAL. Writing this special log entry in sync mode automatically
+	// flushes all previous writes, ensuring database durability.
+	return d.db.LogData(nil, pebble.Sync)
+}
+
 // Path returns the path to the database directory.
 func (d *Database) Path() string {
 	return d.fn
@@ -576,7 +703,11 @@ func (b *batch) Write() error {
 	if b.db.closed {
 		return pebble.ErrClosed
 	}
-	return b.b.Commit(b.db.writeOptions)
+	if err := b.b.Commit(b.db.writeOptions); err != nil {
+		return err
+	}
+	b.db.markLogicalWrite(b.size)
+	return nil
 }
 
 // Reset resets the batch for reuse.
@@ -24,6 +24,7 @@ import (
 
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/ethdb/dbtest"
+	"github.com/ethereum/go-ethereum/metrics"
 )
 
 func TestPebbleDB(t *testing.T) {
@@ -55,3 +56,158 @@ func BenchmarkPebbleDB(b *testing.B) {
 		}
 	})
 }
+
+func TestConfigApply(t *testing.T) {
+	opt := new(pebble.Options).EnsureDefaults()
+	config := &Config{
+		MemTableSize:             64 * 1024 * 1024,
+		L0CompactionThreshold:    8,
+		L0StopWritesThreshold:    32,
+		MaxConcurrentCompactions: 3,
+		DisableWAL:               true,
+	}
+	config.apply(opt, 1<<31-1)
+
+	if opt.MemTableSize != config.MemTableSize {
+		t.Errorf("memtable size mismatch: have %d, want %d", opt.MemTableSize, config.MemTableSize)
+	}
+	if opt.L0CompactionThreshold != config.L0CompactionThreshold {
+		t.Errorf("compaction threshold mismatch: have %d, want %d", opt.L0CompactionThreshold, config.L0CompactionThreshold)
+	}
+	if opt.L0StopWritesThreshold != config.L0StopWritesThreshold {
+		t.Errorf("stop writes threshold mismatch: have %d, want %d", opt.L0StopWritesThreshold, config.L0StopWritesThreshold)
+	}
+	if have := opt.MaxConcurrentCompactions(); have != config.MaxConcurrentCompactions {
+		t.Errorf("compactions mismatch: have %d, want %d", have, config.MaxConcurrentCompactions)
+	}
+	if !opt.DisableWAL {
+		t.Error("write-ahead log not disabled")
+	}
+	// Oversized memory tables must be capped
+	config.apply(opt, 1024)
+	if opt.MemTableSize != 1023 {
+		t.Errorf("memtable size not capped: have %d, want %d", opt.MemTableSize, 1023)
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	defaults := new(pebble.Options).EnsureDefaults()
+
+	tests := []struct {
+		config *Config
+		valid  bool
+	}{
+		{&Config{}, true},
+		{&Config{L0CompactionThreshold: 8, L0StopWritesThreshold: 32}, true},
+		{&Config{L0CompactionThreshold: 8, L0StopWritesThreshold: 4}, false},
+		// A lone stop writes threshold is checked against the default compaction threshold
+		{&Config{L0StopWritesThreshold: defaults.L0CompactionThreshold - 1}, false},
+		// A lone compaction threshold is checked against the default stop writes threshold
+		{&Config{L0CompactionThreshold: defaults.L0StopWritesThreshold + 1}, false},
+		{&Config{MaxConcurrentCompactions: -1}, false},
+	}
+	for i, tt := range tests {
+		if err := tt.config.Validate(); (err == nil) != tt.valid {
+			t.Errorf("test %d: validity mismatch: have %v, want valid %v", i, err, tt.valid)
+		}
+	}
+}
+
+func TestNoSync(t *testing.T) {
+	dir := t.TempDir()
+
+	db, err := NewWithConfig(dir, 16, 16, "", false, false, &Config{NoSync: true})
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	if db.writeOptions.Sync {
+		t.Fatal("writes are synced")
+	}
+	if err := db.Put([]byte("key"), []byte{0x1}); err != nil {
+		t.Fatalf("failed to write: %v", err)
+	}
+	if err := db.SyncKeyValue(); err != nil {
+		t.Fatalf("failed to sync: %v", err)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatalf("failed to close database: %v", err)
+	}
+	db, err = New(dir, 16, 16, "", false, false)
+	if err != nil {
+		t.Fatalf("failed to reopen database: %v", err)
+	}
+	defer db.Close()
+
+	if !db.writeOptions.Sync {
+		t.Fatal("writes are not synced by default")
+	}
+	if have, err := db.Get([]byte("key")); err != nil || len(have) != 1 || have[0] != 0x1 {
+		t.Fatalf("value mismatch: have %x (%v), want 01", have, err)
+	}
+}
+
+func TestDisableWALPersistence(t *testing.T) {
+	dir := t.TempDir()
+
+	db, err := NewWithConfig(dir, 16, 16, "", false, false, &Config{DisableWAL: true})
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	if err := db.Put([]byte("synced"), []byte{0x1}); err != nil {
+		t.Fatalf("failed to write: %v", err)
+	}
+	if err := db.SyncKeyValue(); err != nil {
+		t.Fatalf("failed to sync: %v", err)
+	}
+	if err := db.Put([]byte("closed"), []byte{0x2}); err != nil {
+		t.Fatalf("failed to write: %v", err)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatalf("failed to close database: %v", err)
+	}
+	// Reopen the database and ensure both the synced and the unsynced writes
+	// (flushed on close) are present.
+	db, err = New(dir, 16, 16, "", false, false)
+	if err != nil {
+		t.Fatalf("failed to reopen database: %v", err)
+	}
+	defer db.Close()
+
+	for key, want := range map[string]byte{"synced": 0x1, "closed": 0x2} {
+		have, err := db.Get([]byte(key))
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", key, err)
+		}
+		if len(have) != 1 || have[0] != want {
+			t.Fatalf("value mismatch for %s: have %x, want %x", key, have, want)
+		}
+	}
+	if err := db.SyncKeyValue(); err != nil {
+		t.Fatalf("failed to sync: %v", err)
+	}
+}
+
+func TestLogicalWriteMeter(t *testing.T) {
+	enabled := metrics.Enabled
+	metrics.Enabled = true
+	defer func() { metrics.Enabled = enabled }()
+
+	db, err := New(t.TempDir(), 16, 16, "pebble/test/", false, true)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	db.Put([]byte("key"), []byte("value"))
+	db.Delete([]byte("key"))
+
+	batch := db.NewBatch()
+	batch.Put([]byte("key1"), []byte("value1"))
+	batch.Put([]byte("key2"), []byte("value2"))
+	if err := batch.Write(); err != nil {
+		t.Fatalf("failed to write batch: %v", err)
+	}
+	if have, want := db.logicalWriteMeter.Snapshot().Count(), int64(8+3+20); have != want {
+		t.Fatalf("logical write mismatch: have %d, want %d", have, want)
+	}
+}
@@ -138,6 +138,10 @@ func (db *Database) Compact(start []byte, limit []byte) error {
 	return nil
 }
 
+func (db *Database) SyncKeyValue() error {
+	return nil
+}
+
 func (db *Database) NewSnapshot() (ethdb.Snapshot, error) {
 	panic("not supported")
 }
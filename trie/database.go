@@ -181,6 +181,24 @@ func (db *Database) Commit(root common.Hash, report bool) error {
 	return db.backend.Commit(root, report)
 }
 
+// ImportNodes writes the given set of pre-hashed trie nodes belonging to the
+// specified state directly into the persistent database. Unlike Update and
+// Commit, nodes are neither buffered in memory nor tracked by references, which
+// makes it suitable for bulk imports of complete tries. As a side effect, all
+// pre-images accumulated up to this point are also written.
+//
+// It's only supported by hash-based database and will return an error for others.
+func (db *Database) ImportNodes(root common.Hash, nodes *trienode.MergedNodeSet) error {
+	hdb, ok := db.backend.(*hashdb.Database)
+	if !ok {
+		return errors.New("not supported")
+	}
+	if db.preimages != nil {
+		db.preimages.commit(true)
+	}
+	return hdb.Import(root, nodes)
+}
+
 // Size returns the storage size of diff layer nodes above the persistent disk
 // layer, the dirty nodes buffered within the disk layer, and the size of cached
 // preimages.
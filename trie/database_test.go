@@ -17,12 +17,18 @@
 package trie
 
 import (
+	"fmt"
 	"strings"
+	"testing"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/trie/triedb/hashdb"
 	"github.com/ethereum/go-ethereum/trie/triedb/pathdb"
+	"github.com/ethereum/go-ethereum/trie/trienode"
 )
 
 // newTestDatabase initializes the trie database with specified scheme.
@@ -43,3 +49,110 @@ func newTestDatabase(diskdb ethdb.Database, scheme string) *Database {
 	}
 	return NewDatabase(diskdb, config)
 }
+
+func TestImportNodes(t *testing.T) {
+	src := NewEmpty(NewDatabase(rawdb.NewMemoryDatabase(), nil))
+	vals := make(map[string]string)
+	for i := 0; i < 1000; i++ {
+		key, val := fmt.Sprintf("key-%d", i), fmt.Sprintf("val-%d", i)
+		src.MustUpdate([]byte(key), []byte(val))
+		vals[key] = val
+	}
+	root, nodes, err := src.Commit(false)
+	if err != nil {
+		t.Fatalf("Failed to commit trie: %v", err)
+	}
+	diskdb := rawdb.NewMemoryDatabase()
+	db := NewDatabase(diskdb, nil)
+	if err := db.ImportNodes(root, trienode.NewWithNodeSet(nodes)); err != nil {
+		t.Fatalf("Failed to import nodes: %v", err)
+	}
+	// The nodes must be persisted right away, without any further commit
+	tr, err := New(TrieID(root), NewDatabase(diskdb, nil))
+	if err != nil {
+		t.Fatalf("Failed to open imported trie: %v", err)
+	}
+	for key, val := range vals {
+		if have := string(tr.MustGet([]byte(key))); have != val {
+			t.Fatalf("Value mismatch for %s: have %s, want %s", key, have, val)
+		}
+	}
+	// Importing nodes which are also buffered as dirty must evict them from
+	// the dirty cache, leaving the trie readable and committable
+	overlapdb := NewDatabase(rawdb.NewMemoryDatabase(), nil)
+	if err := overlapdb.Update(root, types.EmptyRootHash, 0, trienode.NewWithNodeSet(nodes), nil); err != nil {
+		t.Fatalf("Failed to update database: %v", err)
+	}
+	if _, dirties, _ := overlapdb.Size(); dirties == 0 {
+		t.Fatal("Expected dirty nodes before import")
+	}
+	if err := overlapdb.ImportNodes(root, trienode.NewWithNodeSet(nodes)); err != nil {
+		t.Fatalf("Failed to import overlapping nodes: %v", err)
+	}
+	if _, dirties, _ := overlapdb.Size(); dirties != 0 {
+		t.Fatalf("Dirty nodes left after import: %v", dirties)
+	}
+	if err := overlapdb.Commit(root, false); err != nil {
+		t.Fatalf("Failed to commit imported trie: %v", err)
+	}
+	tr, err = New(TrieID(root), overlapdb)
+	if err != nil {
+		t.Fatalf("Failed to open imported trie: %v", err)
+	}
+	for key, val := range vals {
+		if have := string(tr.MustGet([]byte(key))); have != val {
+			t.Fatalf("Value mismatch for %s: have %s, want %s", key, have, val)
+		}
+	}
+	// Importing a node set without the root must be rejected up front
+	other := NewEmpty(NewDatabase(rawdb.NewMemoryDatabase(), nil))
+	other.MustUpdate([]byte("key"), []byte("val"))
+	_, otherNodes, _ := other.Commit(false)
+
+	emptydb := rawdb.NewMemoryDatabase()
+	if err := NewDatabase(emptydb, nil).ImportNodes(common.Hash{0x1}, trienode.NewWithNodeSet(otherNodes)); err == nil {
+		t.Fatal("Expected error for missing root")
+	}
+	it := emptydb.NewIterator(nil, nil)
+	defer it.Release()
+	if it.Next() {
+		t.Fatalf("Unexpected data written for rejected import: %x", it.Key())
+	}
+	// Path-based database doesn't support importing
+	if err := newTestDatabase(rawdb.NewMemoryDatabase(), rawdb.PathScheme).ImportNodes(root, trienode.NewWithNodeSet(nodes)); err == nil {
+		t.Fatal("Expected error for path-based database")
+	}
+}
+
+func BenchmarkImportNodes(b *testing.B) {
+	addresses, accounts := makeAccounts(10000)
+	src := NewEmpty(NewDatabase(rawdb.NewMemoryDatabase(), nil))
+	for i := range addresses {
+		src.MustUpdate(crypto.Keccak256(addresses[i][:]), accounts[i])
+	}
+	root, nodes, err := src.Commit(false)
+	if err != nil {
+		b.Fatalf("Failed to commit trie: %v", err)
+	}
+	b.Run("import", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			db := NewDatabase(rawdb.NewMemoryDatabase(), nil)
+			if err := db.ImportNodes(root, trienode.NewWithNodeSet(nodes)); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("update+commit", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			db := NewDatabase(rawdb.NewMemoryDatabase(), nil)
+			if err := db.Update(root, types.EmptyRootHash, 0, trienode.NewWithNodeSet(nodes), nil); err != nil {
+				b.Fatal(err)
+			}
+			if err := db.Commit(root, false); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
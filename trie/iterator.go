@@ -20,12 +20,14 @@ import (
 	"bytes"
 	"container/heap"
 	"errors"
+	"fmt"
 
 	zktrie "github.com/kroma-network/zktrie/trie"
 	zkt "github.com/kroma-network/zktrie/types"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/trie/zk"
 )
 
@@ -75,6 +77,74 @@ func (it *Iterator) Prove() [][]byte {
 	return it.nodeIt.LeafProof()
 }
 
+// PreimageResolver looks up the preimage of a hashed trie key, returning nil if
+// the preimage is not known.
+type PreimageResolver func(hash common.Hash) []byte
+
+// LeafIterator is a leaf-only iterator that traverses either a merkle patricia
+// trie or a zktrie, exposing the hashed key of every leaf in the same form for
+// both trie types and resolving it back to its preimage if possible.
+type LeafIterator struct {
+	it        *Iterator
+	isZk      bool
+	resolvers []PreimageResolver
+
+	Hash     common.Hash // Hashed key of the leaf the iterator is positioned on
+	Preimage []byte      // Preimage of the hashed key, nil if it couldn't be resolved
+	Value    []byte      // Raw value of the leaf the iterator is positioned on
+	Err      error
+}
+
+// NewLeafIterator creates a new leaf iterator from a node iterator. The given
+// resolvers are consulted in order to find the preimage of each leaf's hashed
+// key, e.g. Database.Preimage followed by any external preimage source. Every
+// preimage found is verified against the hashed key, and the iteration aborts
+// with an error if it doesn't match. Note that the value returned by the
+// iterator is raw, as for Iterator.
+func NewLeafIterator(it NodeIterator, isZk bool, resolvers ...PreimageResolver) *LeafIterator {
+	return &LeafIterator{
+		it:        NewIterator(it),
+		isZk:      isZk,
+		resolvers: resolvers,
+	}
+}
+
+// Next moves the iterator forward one leaf.
+func (it *LeafIterator) Next() bool {
+	if !it.it.Next() {
+		it.Hash, it.Preimage, it.Value = common.Hash{}, nil, nil
+		it.Err = it.it.Err
+		return false
+	}
+	it.Hash = *IteratorKeyToHash(it.it.Key, it.isZk)
+	it.Value = it.it.Value
+	it.Preimage = nil
+	for _, resolve := range it.resolvers {
+		if preimage := resolve(it.Hash); preimage != nil {
+			if err := it.validatePreimage(preimage); err != nil {
+				it.Hash, it.Value = common.Hash{}, nil
+				it.Err = err
+				return false
+			}
+			it.Preimage = preimage
+			break
+		}
+	}
+	return true
+}
+
+// validatePreimage checks that the preimage returned by a resolver hashes to
+// the key of the current leaf, using the key hashing of the trie type.
+func (it *LeafIterator) validatePreimage(preimage []byte) error {
+	if it.isZk {
+		return zk.ValidatePreimage(it.Hash, preimage)
+	}
+	if hash := crypto.Keccak256Hash(preimage); hash != it.Hash {
+		return fmt.Errorf("preimage mismatch: have %x, want %x", hash, it.Hash)
+	}
+	return nil
+}
+
 // NodeIterator is an iterator to traverse the trie pre-order.
 type NodeIterator interface {
 	// Next moves the iterator to the next node. If the parameter is false, any child
@@ -18,6 +18,7 @@ package trie
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"math/rand"
 	"testing"
@@ -29,6 +30,7 @@ import (
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+	"github.com/ethereum/go-ethereum/trie/triedb/hashdb"
 	"github.com/ethereum/go-ethereum/trie/trienode"
 	"github.com/ethereum/go-ethereum/trie/zk"
 	"golang.org/x/exp/slices"
@@ -723,3 +725,163 @@ func TestMerkleTreeIterator(t *testing.T) {
 		}
 	})
 }
+
+func TestLeafIterator(t *testing.T) {
+	t.Run("merkle patricia trie", func(t *testing.T) {
+		db := NewDatabase(rawdb.NewMemoryDatabase(), &Config{Preimages: true, HashDB: hashdb.Defaults})
+		tr, _ := NewStateTrie(TrieID(types.EmptyRootHash), db)
+		for _, val := range testdata1 {
+			tr.MustUpdate([]byte(val.k), []byte(val.v))
+		}
+		root, nodes, _ := tr.Commit(false)
+		db.Update(root, types.EmptyRootHash, 0, trienode.NewWithNodeSet(nodes), nil)
+
+		tr, _ = NewStateTrie(TrieID(root), db)
+		testLeafIterator(t, NewLeafIterator(tr.MustNodeIterator(nil), false, db.Preimage),
+			func(k string) []byte { return []byte(k) },
+			func(v string) []byte { return []byte(v) })
+
+		// Preimages not matching the hashed keys must be rejected
+		bogus := func(common.Hash) []byte { return []byte("bogus") }
+		it := NewLeafIterator(tr.MustNodeIterator(nil), false, bogus, db.Preimage)
+		if it.Next() || it.Err == nil {
+			t.Fatal("expected error for mismatching preimage")
+		}
+	})
+	t.Run("zk merkle tree", func(t *testing.T) {
+		config := *ZkHashDefaults
+		config.Preimages = true
+		db := NewDatabase(rawdb.NewMemoryDatabase(), &config)
+		tree := NewEmptyZkMerkleStateTrie(db)
+		for _, val := range testdata1 {
+			tree.MustUpdate(common.LeftPadBytes([]byte(val.k), 32), common.LeftPadBytes([]byte(val.v), 32))
+		}
+		root, _, _ := tree.Commit(false)
+		db.Commit(root, false)
+
+		tree, _ = NewZkMerkleStateTrie(root, db)
+		testLeafIterator(t, NewLeafIterator(tree.MustNodeIterator(nil), true, db.Preimage),
+			func(k string) []byte { return common.LeftPadBytes([]byte(k), 32) },
+			func(v string) []byte { return common.LeftPadBytes([]byte(v), 32) })
+
+		// Preimages not matching the hashed keys must be rejected
+		bogus := func(common.Hash) []byte { return common.LeftPadBytes([]byte("bogus"), 32) }
+		it := NewLeafIterator(tree.MustNodeIterator(nil), true, bogus, db.Preimage)
+		if it.Next() || !errors.Is(it.Err, zk.ErrPreimageMismatch) {
+			t.Fatalf("unexpected error for mismatching preimage: have %v, want %v", it.Err, zk.ErrPreimageMismatch)
+		}
+	})
+	t.Run("unresolved preimages", func(t *testing.T) {
+		tr := NewEmpty(NewDatabase(rawdb.NewMemoryDatabase(), nil))
+		for _, val := range testdata1 {
+			tr.MustUpdate([]byte(val.k), []byte(val.v))
+		}
+		missing := func(common.Hash) []byte { return nil }
+		it := NewLeafIterator(tr.MustNodeIterator(nil), false, missing)
+		count := 0
+		for ; it.Next(); count++ {
+			if it.Preimage != nil {
+				t.Fatalf("unexpected preimage %x for %x", it.Preimage, it.Hash)
+			}
+		}
+		if count != len(testdata1) {
+			t.Fatalf("leaf count mismatch: have %d, want %d", count, len(testdata1))
+		}
+	})
+}
+
+func testLeafIterator(t *testing.T, it *LeafIterator, preimage func(k string) []byte, value func(v string) []byte) {
+	want := make(map[string][]byte)
+	for _, val := range testdata1 {
+		want[string(preimage(val.k))] = value(val.v)
+	}
+	for it.Next() {
+		if it.Preimage == nil {
+			t.Fatalf("missing preimage for %x", it.Hash)
+		}
+		val, ok := want[string(it.Preimage)]
+		if !ok {
+			t.Fatalf("unexpected preimage %x for %x", it.Preimage, it.Hash)
+		}
+		if !bytes.Equal(it.Value, val) {
+			t.Fatalf("value mismatch for %x: have %x, want %x", it.Preimage, it.Value, val)
+		}
+		delete(want, string(it.Preimage))
+	}
+	if it.Err != nil {
+		t.Fatalf("iterator error: %v", it.Err)
+	}
+	if len(want) != 0 {
+		t.Fatalf("%d leaves not iterated", len(want))
+	}
+}
+
+func BenchmarkLeafIterator(b *testing.B) {
+	b.Run("mpt/1K", func(b *testing.B) { benchmarkLeafIterator(b, 1000, false) })
+	b.Run("mpt/10K", func(b *testing.B) { benchmarkLeafIterator(b, 10000, false) })
+	b.Run("zk/1K", func(b *testing.B) { benchmarkLeafIterator(b, 1000, true) })
+	b.Run("zk/10K", func(b *testing.B) { benchmarkLeafIterator(b, 10000, true) })
+}
+
+// benchmarkLeafIterator measures iterating an account trie of the given size,
+// resolving every key from the persisted preimage store.
+func benchmarkLeafIterator(b *testing.B, size int, isZk bool) {
+	var (
+		diskdb              = rawdb.NewMemoryDatabase()
+		addresses, accounts = makeAccounts(size)
+		root                common.Hash
+		config              *Config
+	)
+	if isZk {
+		zkConfig := *ZkHashDefaults
+		zkConfig.Preimages = true
+		config = &zkConfig
+
+		db := NewDatabase(diskdb, config)
+		tree := NewEmptyZkMerkleStateTrie(db)
+		for i := range addresses {
+			tree.MustUpdate(common.LeftPadBytes(addresses[i][:], 32), crypto.Keccak256(accounts[i]))
+		}
+		root, _, _ = tree.Commit(false)
+		db.Commit(root, false)
+	} else {
+		config = &Config{Preimages: true, HashDB: hashdb.Defaults}
+
+		db := NewDatabase(diskdb, config)
+		tr, _ := NewStateTrie(TrieID(types.EmptyRootHash), db)
+		for i := range addresses {
+			tr.MustUpdate(addresses[i][:], accounts[i])
+		}
+		var nodes *trienode.NodeSet
+		root, nodes, _ = tr.Commit(false)
+		db.Update(root, types.EmptyRootHash, 0, trienode.NewWithNodeSet(nodes), nil)
+		db.Commit(root, false)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var (
+			db    = NewDatabase(diskdb, config)
+			nodes NodeIterator
+		)
+		if isZk {
+			tree, _ := NewZkMerkleStateTrie(root, db)
+			nodes = tree.MustNodeIterator(nil)
+		} else {
+			tr, _ := NewStateTrie(TrieID(root), db)
+			nodes = tr.MustNodeIterator(nil)
+		}
+		it, count := NewLeafIterator(nodes, isZk, db.Preimage), 0
+		for ; it.Next(); count++ {
+			if it.Preimage == nil {
+				b.Fatalf("missing preimage for %x", it.Hash)
+			}
+		}
+		if it.Err != nil {
+			b.Fatal(it.Err)
+		}
+		if count != size {
+			b.Fatalf("leaf count mismatch: have %d, want %d", count, size)
+		}
+	}
+}
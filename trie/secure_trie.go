@@ -17,8 +17,11 @@
 package trie
 
 import (
+	"sync"
+
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/ethereum/go-ethereum/trie/trienode"
 )
@@ -176,6 +179,33 @@ func (t *StateTrie) UpdateAccount(address common.Address, acc *types.StateAccoun
 	return nil
 }
 
+// UpdateStorageByHash does the same thing as UpdateStorage, however it expects
+// the slot key to be already hashed, saving the keccak in the insert path. The
+// hash must be the keccak256 of key, which is recorded as its preimage.
+func (t *StateTrie) UpdateStorageByHash(keyHash common.Hash, key, value []byte) error {
+	v, _ := rlp.EncodeToBytes(value)
+	if err := t.trie.Update(keyHash.Bytes(), v); err != nil {
+		return err
+	}
+	t.getSecKeyCache()[string(keyHash.Bytes())] = common.CopyBytes(key)
+	return nil
+}
+
+// UpdateAccountByHash does the same thing as UpdateAccount, however it expects
+// the account hash to be already computed. The hash must be the keccak256 of
+// address, which is recorded as its preimage.
+func (t *StateTrie) UpdateAccountByHash(addrHash common.Hash, address common.Address, acc *types.StateAccount) error {
+	data, err := rlp.EncodeToBytes(acc)
+	if err != nil {
+		return err
+	}
+	if err := t.trie.Update(addrHash.Bytes(), data); err != nil {
+		return err
+	}
+	t.getSecKeyCache()[string(addrHash.Bytes())] = address.Bytes()
+	return nil
+}
+
 func (t *StateTrie) UpdateContractCode(_ common.Address, _ common.Hash, _ []byte) error {
 	return nil
 }
@@ -266,6 +296,39 @@ func (t *StateTrie) MustNodeIterator(start []byte) NodeIterator {
 	return t.trie.MustNodeIterator(start)
 }
 
+// HashKeys computes the keccak256 hashes of the given keys, spreading the work
+// over the requested number of goroutines. The results can be fed into the
+// ByHash update methods of StateTrie.
+func HashKeys(keys [][]byte, threads int) []common.Hash {
+	hashes := make([]common.Hash, len(keys))
+	if len(keys) == 0 {
+		return hashes
+	}
+	threads = min(max(threads, 1), len(keys))
+
+	var (
+		chunk = (len(keys) + threads - 1) / threads
+		wg    sync.WaitGroup
+	)
+	for start := 0; start < len(keys); start += chunk {
+		end := min(start+chunk, len(keys))
+
+		wg.Add(1)
+		go func(start, end int) {
+			defer wg.Done()
+
+			hasher := crypto.NewKeccakState()
+			for i := start; i < end; i++ {
+				hasher.Reset()
+				hasher.Write(keys[i])
+				hasher.Read(hashes[i][:])
+			}
+		}(start, end)
+	}
+	wg.Wait()
+	return hashes
+}
+
 // hashKey returns the hash of key as an ephemeral buffer.
 // The caller must not hold onto the return value because it will become
 // invalid on the next call to hashKey or secKey.
@@ -19,6 +19,7 @@ package trie
 import (
 	"bytes"
 	"fmt"
+	"math/big"
 	"runtime"
 	"sync"
 	"testing"
@@ -111,6 +112,70 @@ func TestSecureGetKey(t *testing.T) {
 	}
 }
 
+// Tests that updating a trie with pre-hashed keys yields the same trie and the
+// same preimages as letting the trie hash the keys itself.
+func TestStateTrieUpdateByHash(t *testing.T) {
+	var (
+		plain  = newEmptySecure()
+		hashed = newEmptySecure()
+		keys   [][]byte
+	)
+	for i := 0; i < 64; i++ {
+		keys = append(keys, common.LeftPadBytes([]byte{byte(i)}, 20))
+	}
+	for i, hash := range HashKeys(keys, 4) {
+		addr := common.BytesToAddress(keys[i])
+		acc := &types.StateAccount{Nonce: uint64(i), Balance: big.NewInt(int64(i)), Root: types.EmptyRootHash, CodeHash: types.EmptyCodeHash.Bytes()}
+
+		if err := plain.UpdateAccount(addr, acc); err != nil {
+			t.Fatalf("failed to update account: %v", err)
+		}
+		if err := hashed.UpdateAccountByHash(hash, addr, acc); err != nil {
+			t.Fatalf("failed to update account by hash: %v", err)
+		}
+		if k := hashed.GetKey(hash.Bytes()); !bytes.Equal(k, addr.Bytes()) {
+			t.Errorf("account %d: preimage mismatch: have %x, want %x", i, k, addr)
+		}
+	}
+	for i, hash := range HashKeys(keys, 4) {
+		if err := plain.UpdateStorage(common.Address{}, keys[i], []byte{byte(i + 1)}); err != nil {
+			t.Fatalf("failed to update slot: %v", err)
+		}
+		if err := hashed.UpdateStorageByHash(hash, keys[i], []byte{byte(i + 1)}); err != nil {
+			t.Fatalf("failed to update slot by hash: %v", err)
+		}
+		if k := hashed.GetKey(hash.Bytes()); !bytes.Equal(k, keys[i]) {
+			t.Errorf("slot %d: preimage mismatch: have %x, want %x", i, k, keys[i])
+		}
+	}
+	if have, want := hashed.Hash(), plain.Hash(); have != want {
+		t.Fatalf("root mismatch: have %x, want %x", have, want)
+	}
+}
+
+// Tests that hashing keys in parallel matches hashing them one by one,
+// regardless of the number of threads.
+func TestHashKeys(t *testing.T) {
+	var keys [][]byte
+	for i := 0; i < 100; i++ {
+		keys = append(keys, []byte(fmt.Sprintf("key-%d", i)))
+	}
+	for _, threads := range []int{-1, 0, 1, 3, 7, 100, 1000} {
+		hashes := HashKeys(keys, threads)
+		if len(hashes) != len(keys) {
+			t.Fatalf("threads %d: hash count mismatch: have %d, want %d", threads, len(hashes), len(keys))
+		}
+		for i, hash := range hashes {
+			if want := crypto.Keccak256Hash(keys[i]); hash != want {
+				t.Fatalf("threads %d: hash %d mismatch: have %x, want %x", threads, i, hash, want)
+			}
+		}
+	}
+	if hashes := HashKeys(nil, 4); len(hashes) != 0 {
+		t.Fatalf("unexpected hashes for empty input: %v", hashes)
+	}
+}
+
 func TestStateTrieConcurrency(t *testing.T) {
 	// Create an initial trie and copy if for concurrent access
 	_, trie, _ := makeTestStateTrie()
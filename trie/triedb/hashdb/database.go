@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"reflect"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/VictoriaMetrics/fastcache"
@@ -57,6 +58,17 @@ var (
 	memcacheCommitTimeTimer  = metrics.NewRegisteredResettingTimer("hashdb/memcache/commit/time", nil)
 	memcacheCommitNodesMeter = metrics.NewRegisteredMeter("hashdb/memcache/commit/nodes", nil)
 	memcacheCommitBytesMeter = metrics.NewRegisteredMeter("hashdb/memcache/commit/bytes", nil)
+
+	memcacheDirtySizeGauge = metrics.NewRegisteredGauge("hashdb/memcache/dirty/size", nil)
+
+	zkMemcacheCleanHitMeter   = metrics.NewRegisteredMeter("hashdb/zk/memcache/clean/hit", nil)
+	zkMemcacheCleanMissMeter  = metrics.NewRegisteredMeter("hashdb/zk/memcache/clean/miss", nil)
+	zkMemcacheCleanReadMeter  = metrics.NewRegisteredMeter("hashdb/zk/memcache/clean/read", nil)
+	zkMemcacheCleanWriteMeter = metrics.NewRegisteredMeter("hashdb/zk/memcache/clean/write", nil)
+
+	zkMemcacheDirtyHitMeter  = metrics.NewRegisteredMeter("hashdb/zk/memcache/dirty/hit", nil)
+	zkMemcacheDirtyMissMeter = metrics.NewRegisteredMeter("hashdb/zk/memcache/dirty/miss", nil)
+	zkMemcacheDirtyReadMeter = metrics.NewRegisteredMeter("hashdb/zk/memcache/dirty/read", nil)
 )
 
 // ChildResolver defines the required method to decode the provided
@@ -102,6 +114,8 @@ type Database struct {
 	dirtiesSize  common.StorageSize // Storage size of the dirty node cache (exc. metadata)
 	childrenSize common.StorageSize // Storage size of the external children tracking
 
+	stats cacheStats // Cache hits and misses since last commit
+
 	lock sync.RWMutex
 }
 
@@ -190,6 +204,7 @@ func (db *Database) node(hash common.Hash) ([]byte, error) {
 		if enc := db.cleans.Get(nil, hash[:]); enc != nil {
 			memcacheCleanHitMeter.Mark(1)
 			memcacheCleanReadMeter.Mark(int64(len(enc)))
+			db.stats.cleanHit.Add(1)
 			return enc, nil
 		}
 	}
@@ -204,9 +219,11 @@ func (db *Database) node(hash common.Hash) ([]byte, error) {
 	if dirty != nil {
 		memcacheDirtyHitMeter.Mark(1)
 		memcacheDirtyReadMeter.Mark(int64(len(dirty.node)))
+		db.stats.dirtyHit.Add(1)
 		return dirty.node, nil
 	}
 	memcacheDirtyMissMeter.Mark(1)
+	db.stats.dirtyMiss.Add(1)
 
 	// Content unavailable in memory, attempt to retrieve from disk
 	enc := rawdb.ReadLegacyTrieNode(db.diskdb, hash)
@@ -215,6 +232,7 @@ func (db *Database) node(hash common.Hash) ([]byte, error) {
 			db.cleans.Set(hash[:], enc)
 			memcacheCleanMissMeter.Mark(1)
 			memcacheCleanWriteMeter.Mark(int64(len(enc)))
+			db.stats.cleanMiss.Add(1)
 		}
 		return enc, nil
 	}
@@ -413,14 +431,13 @@ func (db *Database) Commit(node common.Hash, report bool) error {
 	// outside code doesn't see an inconsistent state (referenced data removed from
 	// memory cache during commit but not yet in persistent storage). This is ensured
 	// by only uncaching existing data when the database write finalizes.
-	start := time.Now()
 	batch := db.diskdb.NewBatch()
 
 	// Move the trie itself into the batch, flushing if enough data is accumulated
-	nodes, storage := len(db.dirties), db.dirtiesSize
+	progress := newCommitProgress(len(db.dirties), db.dirtiesSize, report)
 
 	uncacher := &cleaner{db}
-	if err := db.commit(node, batch, uncacher); err != nil {
+	if err := db.commit(node, batch, uncacher, progress); err != nil {
 		log.Error("Failed to commit trie from trie database", "err", err)
 		return err
 	}
@@ -436,16 +453,17 @@ func (db *Database) Commit(node common.Hash, report bool) error {
 	batch.Reset()
 
 	// Reset the storage counters and bumped metrics
-	memcacheCommitTimeTimer.Update(time.Since(start))
-	memcacheCommitBytesMeter.Mark(int64(storage - db.dirtiesSize))
-	memcacheCommitNodesMeter.Mark(int64(nodes - len(db.dirties)))
+	progress.mark(len(db.dirties), db.dirtiesSize)
+	memcacheCommitTimeTimer.Update(time.Since(progress.start))
 
 	logger := log.Info
 	if !report {
 		logger = log.Debug
 	}
-	logger("Persisted trie from memory database", "nodes", nodes-len(db.dirties)+int(db.flushnodes), "size", storage-db.dirtiesSize+db.flushsize, "time", time.Since(start)+db.flushtime,
-		"gcnodes", db.gcnodes, "gcsize", db.gcsize, "gctime", db.gctime, "livenodes", len(db.dirties), "livesize", db.dirtiesSize)
+	nodes, storage := progress.nodes, progress.size
+	context := []interface{}{"nodes", nodes - len(db.dirties) + int(db.flushnodes), "size", storage - db.dirtiesSize + db.flushsize, "time", time.Since(progress.start) + db.flushtime,
+		"gcnodes", db.gcnodes, "gcsize", db.gcsize, "gctime", db.gctime, "livenodes", len(db.dirties), "livesize", db.dirtiesSize}
+	logger("Persisted trie from memory database", append(context, db.stats.reset()...)...)
 
 	// Reset the garbage collection statistics
 	db.gcnodes, db.gcsize, db.gctime = 0, 0, 0
@@ -455,7 +473,7 @@ func (db *Database) Commit(node common.Hash, report bool) error {
 }
 
 // commit is the private locked version of Commit.
-func (db *Database) commit(hash common.Hash, batch ethdb.Batch, uncacher *cleaner) error {
+func (db *Database) commit(hash common.Hash, batch ethdb.Batch, uncacher *cleaner, progress *commitProgress) error {
 	// If the node does not exist, it's a previously committed node
 	node, ok := db.dirties[hash]
 	if !ok {
@@ -466,7 +484,7 @@ func (db *Database) commit(hash common.Hash, batch ethdb.Batch, uncacher *cleane
 	// Dereference all children and delete the node
 	node.forChildren(db.resolver, func(child common.Hash) {
 		if err == nil {
-			err = db.commit(child, batch, uncacher)
+			err = db.commit(child, batch, uncacher, progress)
 		}
 	})
 	if err != nil {
@@ -483,10 +501,101 @@ func (db *Database) commit(hash common.Hash, batch ethdb.Batch, uncacher *cleane
 			return err
 		}
 		batch.Reset()
+
+		progress.mark(len(db.dirties), db.dirtiesSize)
+		progress.log(len(db.dirties), db.dirtiesSize)
 	}
 	return nil
 }
 
+// commitProgress tracks the amount of data flushed by a single, potentially
+// very long running, commit operation. It's used to surface the progress via
+// metrics and logs before the whole commit finishes.
+type commitProgress struct {
+	start  time.Time // Timestamp when the commit was started
+	logged time.Time // Timestamp when the progress was last logged
+	report bool      // Flag whether the progress logs are displayed in info level
+
+	nodes int                // Number of dirty nodes when the commit was started
+	size  common.StorageSize // Storage size of dirty nodes when the commit was started
+
+	markedNodes int                // Number of flushed nodes already reported to metrics
+	markedSize  common.StorageSize // Storage size of flushed nodes already reported to metrics
+}
+
+// newCommitProgress creates a progress tracker for a commit starting with the
+// given dirty cache content.
+func newCommitProgress(nodes int, size common.StorageSize, report bool) *commitProgress {
+	return &commitProgress{
+		start:  time.Now(),
+		logged: time.Now(),
+		report: report,
+		nodes:  nodes,
+		size:   size,
+	}
+}
+
+// mark bumps the commit metrics with the nodes flushed since the last call,
+// given the remaining content of the dirty cache.
+func (p *commitProgress) mark(livenodes int, livesize common.StorageSize) {
+	flushed, written := p.nodes-livenodes, p.size-livesize
+
+	memcacheCommitNodesMeter.Mark(int64(flushed - p.markedNodes))
+	memcacheCommitBytesMeter.Mark(int64(written - p.markedSize))
+	memcacheDirtySizeGauge.Update(int64(livesize))
+
+	p.markedNodes, p.markedSize = flushed, written
+}
+
+// log periodically reports the progress of the commit, given the remaining
+// content of the dirty cache.
+func (p *commitProgress) log(livenodes int, livesize common.StorageSize) {
+	if time.Since(p.logged) < 8*time.Second {
+		return
+	}
+	logger := log.Info
+	if !p.report {
+		logger = log.Debug
+	}
+	var (
+		elapsed = time.Since(p.start)
+		flushed = p.nodes - livenodes
+		written = p.size - livesize
+	)
+	logger("Persisting trie from memory database", "nodes", flushed, "size", written,
+		"livenodes", livenodes, "livesize", livesize,
+		"nodes/s", fmt.Sprintf("%.0f", float64(flushed)/elapsed.Seconds()),
+		"bytes/s", common.StorageSize(float64(written)/elapsed.Seconds()),
+		"elapsed", common.PrettyDuration(elapsed))
+	p.logged = time.Now()
+}
+
+// cacheStats counts the hits and misses of the node caches, so that their hit
+// rates can be reported along with the commit statistics.
+type cacheStats struct {
+	cleanHit, cleanMiss atomic.Uint64
+	dirtyHit, dirtyMiss atomic.Uint64
+}
+
+// reset returns the hit rates of the clean and dirty caches as log context and
+// clears the counters.
+func (s *cacheStats) reset() []interface{} {
+	var ctx []interface{}
+	for _, c := range []struct {
+		name      string
+		hit, miss *atomic.Uint64
+	}{
+		{"cleanhits", &s.cleanHit, &s.cleanMiss},
+		{"dirtyhits", &s.dirtyHit, &s.dirtyMiss},
+	} {
+		hit, miss := c.hit.Swap(0), c.miss.Swap(0)
+		if hit+miss > 0 {
+			ctx = append(ctx, c.name, fmt.Sprintf("%.2f%%", float64(hit)*100/float64(hit+miss)))
+		}
+	}
+	return ctx
+}
+
 // cleaner is a database batch replayer that takes a batch of write operations
 // and cleans up the trie database from anything written to disk.
 type cleaner struct {
@@ -599,6 +708,86 @@ func (db *Database) Update(root common.Hash, parent common.Hash, block uint64, n
 	return nil
 }
 
+// Import writes the nodes contained in the provided node set straight into the
+// persistent database, bypassing the dirty cache and its reference tracking.
+// It's meant for bulk imports of complete tries, e.g. when converting state
+// from another representation, where the whole trie is known up front and
+// there is nothing to garbage collect. Deletions are ignored as in Update.
+//
+// The root node must either be contained in the set or already be present in
+// the database, otherwise an error is returned before anything is written.
+//
+// Nodes are flushed in IdealBatchSize chunks to bound memory usage. The batch
+// interface offers no control over durability, so the import is finalized by
+// a single sync of the key-value store; on stores configured without a write
+// ahead log this is the only point at which the imported nodes become durable.
+func (db *Database) Import(root common.Hash, nodes *trienode.MergedNodeSet) error {
+	if root != types.EmptyRootHash && !containsRoot(nodes, root) && !rawdb.HasLegacyTrieNode(db.diskdb, root) {
+		return fmt.Errorf("imported trie root %x is missing", root)
+	}
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	var (
+		start   = time.Now()
+		batch   = db.diskdb.NewBatch()
+		count   int
+		size    common.StorageSize
+		uncache = &cleaner{db}
+	)
+	flush := func() error {
+		if err := batch.Write(); err != nil {
+			return err
+		}
+		// Drop any overlapping dirty nodes, moving them into the clean cache
+		if err := batch.Replay(uncache); err != nil {
+			return err
+		}
+		batch.Reset()
+		return nil
+	}
+	for _, subset := range nodes.Sets {
+		var err error
+		subset.ForEachWithOrder(func(path string, n *trienode.Node) {
+			if err != nil || n.IsDeleted() {
+				return // ignore deletion
+			}
+			rawdb.WriteLegacyTrieNode(batch, n.Hash, n.Blob)
+			count += 1
+			size += common.StorageSize(common.HashLength + len(n.Blob))
+
+			if batch.ValueSize() >= ethdb.IdealBatchSize {
+				err = flush()
+			}
+		})
+		if err != nil {
+			log.Error("Failed to import trie nodes", "err", err)
+			return err
+		}
+	}
+	if err := flush(); err != nil {
+		log.Error("Failed to import trie nodes", "err", err)
+		return err
+	}
+	if err := db.diskdb.SyncKeyValue(); err != nil {
+		log.Error("Failed to sync imported trie nodes", "err", err)
+		return err
+	}
+	log.Debug("Imported trie nodes", "root", root, "nodes", count, "size", size, "time", time.Since(start))
+	return nil
+}
+
+// containsRoot reports whether any trie in the node set has a live root node
+// with the given hash.
+func containsRoot(nodes *trienode.MergedNodeSet, hash common.Hash) bool {
+	for _, subset := range nodes.Sets {
+		if n, ok := subset.Nodes[""]; ok && !n.IsDeleted() && n.Hash == hash {
+			return true
+		}
+	}
+	return false
+}
+
 // Size returns the current storage size of the memory cache in front of the
 // persistent database layer.
 //
@@ -0,0 +1,193 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package hashdb
+
+import (
+	"crypto/rand"
+	"fmt"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// testResolver decodes the node format used by the tests in this package: a
+// single byte holding the number of children, followed by the child hashes
+// and an arbitrary payload.
+type testResolver struct{}
+
+func (testResolver) ForEach(node []byte, onChild func(common.Hash)) {
+	for i := 0; i < int(node[0]); i++ {
+		onChild(common.BytesToHash(node[1+i*common.HashLength : 1+(i+1)*common.HashLength]))
+	}
+}
+
+// makeTestNode assembles a node with the given children and random payload.
+func makeTestNode(children []common.Hash, payload int) (common.Hash, []byte) {
+	blob := []byte{byte(len(children))}
+	for _, child := range children {
+		blob = append(blob, child.Bytes()...)
+	}
+	data := make([]byte, payload)
+	rand.Read(data)
+	blob = append(blob, data...)
+
+	return crypto.Keccak256Hash(blob), blob
+}
+
+// enableCommitMetrics swaps the commit metrics for fresh, enabled instances and
+// restores the originals when the test finishes.
+func enableCommitMetrics(t *testing.T) {
+	enabled := metrics.Enabled
+	nodes, bytes, size := memcacheCommitNodesMeter, memcacheCommitBytesMeter, memcacheDirtySizeGauge
+
+	metrics.Enabled = true
+	memcacheCommitNodesMeter = metrics.NewInactiveMeter()
+	memcacheCommitBytesMeter = metrics.NewInactiveMeter()
+	memcacheDirtySizeGauge = metrics.NewGauge()
+
+	t.Cleanup(func() {
+		metrics.Enabled = enabled
+		memcacheCommitNodesMeter, memcacheCommitBytesMeter, memcacheDirtySizeGauge = nodes, bytes, size
+	})
+}
+
+// Tests that committing a trie spanning several database batches reports each
+// flushed node exactly once in the commit metrics.
+func TestCommitMetrics(t *testing.T) {
+	enableCommitMetrics(t)
+
+	var (
+		diskdb = rawdb.NewMemoryDatabase()
+		db     = New(diskdb, &Config{}, testResolver{})
+		total  common.StorageSize
+		hashes []common.Hash
+	)
+	insert := func(children []common.Hash, payload int) common.Hash {
+		hash, blob := makeTestNode(children, payload)
+		db.insert(hash, blob)
+		total += common.StorageSize(common.HashLength + len(blob))
+		return hash
+	}
+	// Build a two level trie, large enough to be flushed in multiple batches
+	for i := 0; i < 64; i++ {
+		var leaves []common.Hash
+		for j := 0; j < 16; j++ {
+			leaves = append(leaves, insert(nil, 512))
+		}
+		hashes = append(hashes, insert(leaves, 0))
+	}
+	root := insert(hashes, 0)
+	if total < 4*ethdb.IdealBatchSize {
+		t.Fatalf("test trie too small: have %v, want at least %v", total, 4*ethdb.IdealBatchSize)
+	}
+	// Insert a dangling node which is not part of the trie and stays dirty
+	hash, blob := makeTestNode(nil, 512)
+	db.insert(hash, blob)
+	live := common.StorageSize(common.HashLength + len(blob))
+
+	if err := db.Commit(root, false); err != nil {
+		t.Fatalf("Failed to commit trie: %v", err)
+	}
+	if have, want := memcacheCommitNodesMeter.Snapshot().Count(), int64(64*17+1); have != want {
+		t.Errorf("committed nodes mismatch: have %d, want %d", have, want)
+	}
+	if have, want := memcacheCommitBytesMeter.Snapshot().Count(), int64(total); have != want {
+		t.Errorf("committed bytes mismatch: have %d, want %d", have, want)
+	}
+	if have, want := memcacheDirtySizeGauge.Snapshot().Value(), int64(live); have != want {
+		t.Errorf("dirty size mismatch: have %d, want %d", have, want)
+	}
+	if len(db.dirties) != 1 || db.dirtiesSize != live {
+		t.Errorf("dirty cache mismatch: have %d nodes (%v), want 1 node (%v)", len(db.dirties), db.dirtiesSize, live)
+	}
+	if !rawdb.HasLegacyTrieNode(diskdb, root) {
+		t.Error("committed root is missing from disk")
+	}
+}
+
+// Tests that committing a zktrie database spanning several database batches
+// reports each flushed node exactly once in the commit metrics.
+func TestZkCommitMetrics(t *testing.T) {
+	enableCommitMetrics(t)
+
+	var (
+		diskdb = rawdb.NewMemoryDatabase()
+		db     = NewZk(diskdb, &Config{})
+		total  common.StorageSize
+		keys   [][]byte
+	)
+	for i := 0; i < 1024; i++ {
+		key, val := make([]byte, common.HashLength), make([]byte, 512)
+		rand.Read(key)
+		rand.Read(val)
+		db.Put(key, val)
+
+		keys = append(keys, key)
+		total += common.StorageSize(common.HashLength + len(key) + len(val))
+	}
+	if total < 4*ethdb.IdealBatchSize {
+		t.Fatalf("test set too small: have %v, want at least %v", total, 4*ethdb.IdealBatchSize)
+	}
+	if err := db.Commit(common.Hash{}, false); err != nil {
+		t.Fatalf("Failed to commit trie: %v", err)
+	}
+	if have, want := memcacheCommitNodesMeter.Snapshot().Count(), int64(len(keys)); have != want {
+		t.Errorf("committed nodes mismatch: have %d, want %d", have, want)
+	}
+	if have, want := memcacheCommitBytesMeter.Snapshot().Count(), int64(total); have != want {
+		t.Errorf("committed bytes mismatch: have %d, want %d", have, want)
+	}
+	if have := memcacheDirtySizeGauge.Snapshot().Value(); have != 0 {
+		t.Errorf("dirty size mismatch: have %d, want 0", have)
+	}
+	for _, key := range keys {
+		if ok, _ := diskdb.Has(key); !ok {
+			t.Fatalf("committed node %x is missing from disk", key)
+		}
+	}
+}
+
+// Tests that the cache hit rates are computed from the reads since the last
+// commit and omitted for caches which weren't consulted.
+func TestCacheStats(t *testing.T) {
+	diskdb := rawdb.NewMemoryDatabase()
+	db := New(diskdb, &Config{CleanCacheSize: 1024 * 1024}, testResolver{})
+
+	hash, blob := makeTestNode(nil, 32)
+	db.insert(hash, blob)
+	for i := 0; i < 3; i++ {
+		db.node(hash) // dirty hits
+	}
+	if err := db.Commit(hash, false); err != nil {
+		t.Fatalf("Failed to commit: %v", err)
+	}
+	db.node(hash) // clean hit, the node was moved into the clean cache
+	db.cleans.Reset()
+	db.node(hash) // dirty and clean miss
+
+	want := []interface{}{"cleanhits", "50.00%", "dirtyhits", "0.00%"}
+	if have := db.stats.reset(); fmt.Sprint(have) != fmt.Sprint(want) {
+		t.Fatalf("hit rates mismatch: have %v, want %v", have, want)
+	}
+	if have := db.stats.reset(); len(have) != 0 {
+		t.Fatalf("hit rates not reset: %v", have)
+	}
+}
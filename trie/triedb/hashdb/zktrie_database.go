@@ -26,6 +26,7 @@ type ZktrieDatabase struct {
 	cleans  *fastcache.Cache // GC friendly memory cache of clean node RLPs
 	prefix  []byte
 	dirties map[[sha256.Size]byte]*dirty
+	stats   cacheStats // Cache hits and misses since last commit
 
 	lock        sync.RWMutex
 	dirtiesSize common.StorageSize // Storage size of the dirty node cache (exc. metadata)
@@ -70,45 +71,79 @@ func (db *ZktrieDatabase) Update(_ common.Hash, _ common.Hash, _ uint64, _ *trie
 func (db *ZktrieDatabase) Commit(_ common.Hash, report bool) error {
 	beforeDirtyCount, beforeDirtySize := len(db.dirties), db.dirtiesSize
 
-	start := time.Now()
-	if err := db.commitAllDirties(); err != nil {
+	progress := newCommitProgress(beforeDirtyCount, beforeDirtySize, report)
+	if err := db.commitAllDirties(progress); err != nil {
 		return err
 	}
-	memcacheCommitTimeTimer.Update(time.Since(start))
-	memcacheCommitBytesMeter.Mark(int64(beforeDirtySize - db.dirtiesSize))
-	memcacheCommitNodesMeter.Mark(int64(beforeDirtyCount - len(db.dirties)))
+	memcacheCommitTimeTimer.Update(time.Since(progress.start))
 
 	logger := log.Debug
 	if report {
 		logger = log.Info
 	}
-	logger(
-		"Persisted trie from memory database",
-		"nodes", beforeDirtyCount-len(db.dirties),
-		"size", beforeDirtySize-db.dirtiesSize,
-		"time", time.Since(start),
+	context := []interface{}{
+		"nodes", beforeDirtyCount - len(db.dirties),
+		"size", beforeDirtySize - db.dirtiesSize,
+		"time", time.Since(progress.start),
 		"livenodes", len(db.dirties),
 		"livesize", db.dirtiesSize,
-	)
+	}
+	logger("Persisted trie from memory database", append(context, db.stats.reset()...)...)
 	return nil
 }
 
-func (db *ZktrieDatabase) commitAllDirties() error {
-	batch := db.diskdb.NewBatch()
-
-	db.lock.Lock()
+func (db *ZktrieDatabase) commitAllDirties(progress *commitProgress) error {
+	// Snapshot the dirty set, so that no lock is held while writing to disk.
+	// Nodes are only dropped from the dirty cache after they have been written
+	// out, keeping them readable throughout the commit.
+	db.lock.RLock()
+	var (
+		keys    = make([][sha256.Size]byte, 0, len(db.dirties))
+		dirties = make([]*dirty, 0, len(db.dirties))
+	)
 	for hashKey, dirty := range db.dirties {
-		batch.Put(dirty.key, dirty.val)
-		db.removeDirtyByHashKey(hashKey)
+		keys = append(keys, hashKey)
+		dirties = append(dirties, dirty)
 	}
-	db.lock.Unlock()
+	db.lock.RUnlock()
 
-	if err := batch.Write(); err != nil {
-		return err
+	var (
+		batch = db.diskdb.NewBatch()
+		first int
+	)
+	flush := func(last int) error {
+		if err := batch.Write(); err != nil {
+			return err
+		}
+		batch.Reset()
+
+		db.lock.Lock()
+		for i := first; i < last; i++ {
+			// Skip nodes overwritten in the meantime, they are still dirty
+			if db.dirties[keys[i]] == dirties[i] {
+				db.removeDirtyByHashKey(keys[i])
+			}
+		}
+		livenodes, livesize := len(db.dirties), db.dirtiesSize
+		db.lock.Unlock()
+
+		first = last
+		progress.mark(livenodes, livesize)
+		progress.log(livenodes, livesize)
+		return nil
 	}
+	for i, dirty := range dirties {
+		batch.Put(dirty.key, dirty.val)
 
-	batch.Reset()
-	return nil
+		// Flush the batch once it's large enough, so that the progress of a
+		// huge commit can be reported along the way.
+		if batch.ValueSize() >= ethdb.IdealBatchSize {
+			if err := flush(i + 1); err != nil {
+				return err
+			}
+		}
+	}
+	return flush(len(dirties))
 }
 
 func (db *ZktrieDatabase) Close() error { return nil }
@@ -120,24 +155,29 @@ func (db *ZktrieDatabase) Node(hash common.Hash) ([]byte, error) {
 	hashBytes := common.ReverseBytes(hash[:])
 	if db.cleans != nil {
 		if enc := db.cleans.Get(nil, hashBytes); enc != nil {
-			memcacheCleanHitMeter.Mark(1)
-			memcacheCleanReadMeter.Mark(int64(len(enc)))
+			zkMemcacheCleanHitMeter.Mark(1)
+			zkMemcacheCleanReadMeter.Mark(int64(len(enc)))
+			db.stats.cleanHit.Add(1)
 			return enc, nil
 		}
 	}
 
 	if dirty, ok := db.mutexGetDirtyByKey(hashBytes); ok {
-		memcacheDirtyHitMeter.Mark(1)
-		memcacheDirtyReadMeter.Mark(int64(len(dirty.val)))
+		zkMemcacheDirtyHitMeter.Mark(1)
+		zkMemcacheDirtyReadMeter.Mark(int64(len(dirty.val)))
+		db.stats.dirtyHit.Add(1)
 		return dirty.val, nil
 	}
+	zkMemcacheDirtyMissMeter.Mark(1)
+	db.stats.dirtyMiss.Add(1)
 
 	// Content unavailable in memory, attempt to retrieve from disk
 	if enc := rawdb.ReadLegacyTrieNode(db.diskdb, common.BytesToHash(hashBytes)); len(enc) != 0 {
 		if db.cleans != nil {
 			db.cleans.Set(hashBytes, enc)
-			memcacheCleanMissMeter.Mark(1)
-			memcacheCleanWriteMeter.Mark(int64(len(enc)))
+			zkMemcacheCleanMissMeter.Mark(1)
+			zkMemcacheCleanWriteMeter.Mark(int64(len(enc)))
+			db.stats.cleanMiss.Add(1)
 		}
 		return enc, nil
 	}
@@ -153,13 +193,20 @@ func (db *ZktrieDatabase) Put(k, v []byte) error {
 // Get retrieves a value from a key in the Storage
 func (db *ZktrieDatabase) Get(key []byte) ([]byte, error) {
 	if dirty, ok := db.mutexGetDirtyByKey(key); ok {
+		zkMemcacheDirtyHitMeter.Mark(1)
+		zkMemcacheDirtyReadMeter.Mark(int64(len(dirty.val)))
+		db.stats.dirtyHit.Add(1)
 		return dirty.val, nil
 	}
+	zkMemcacheDirtyMissMeter.Mark(1)
+	db.stats.dirtyMiss.Add(1)
+
 	key = db.computeKey(key)
 	if db.cleans != nil {
 		if enc := db.cleans.Get(nil, key); enc != nil {
-			memcacheCleanHitMeter.Mark(1)
-			memcacheCleanReadMeter.Mark(int64(len(enc)))
+			zkMemcacheCleanHitMeter.Mark(1)
+			zkMemcacheCleanReadMeter.Mark(int64(len(enc)))
+			db.stats.cleanHit.Add(1)
 			return enc, nil
 		}
 	}
@@ -169,8 +216,9 @@ func (db *ZktrieDatabase) Get(key []byte) ([]byte, error) {
 	}
 	if db.cleans != nil {
 		db.cleans.Set(key[:], v)
-		memcacheCleanMissMeter.Mark(1)
-		memcacheCleanWriteMeter.Mark(int64(len(v)))
+		zkMemcacheCleanMissMeter.Mark(1)
+		zkMemcacheCleanWriteMeter.Mark(int64(len(v)))
+		db.stats.cleanMiss.Add(1)
 	}
 	return v, err
 }
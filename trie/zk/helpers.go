@@ -0,0 +1,74 @@
+package zk
+
+import (
+	"errors"
+	"fmt"
+
+	zkt "github.com/kroma-network/zktrie/types"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// This file contains the helpers meant to be used outside of the trie packages
+// (e.g. by command line tools) to deal with zktrie keys and nodes, so that they
+// don't need to depend on the tree internals.
+
+var (
+	// ErrPreimageMismatch is returned if a preimage doesn't hash to the expected key.
+	ErrPreimageMismatch = errors.New("preimage mismatch")
+
+	// ErrNodeHashMismatch is returned if a node blob doesn't hash to the expected hash.
+	ErrNodeHashMismatch = errors.New("node hash mismatch")
+)
+
+// HashKey returns the secure key of the given state key (an address or a storage
+// slot) in the big-endian form used by the preimage store and the snapshot.
+func HashKey(key []byte) (common.Hash, error) {
+	hash, err := NewSecureHash(key)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return common.BytesToHash(hash.Bytes()), nil
+}
+
+// MustHashKey is the panicking version of HashKey.
+func MustHashKey(key []byte) common.Hash {
+	hash, err := HashKey(key)
+	if err != nil {
+		panic(err)
+	}
+	return hash
+}
+
+// ValidatePreimage checks whether the given preimage is the state key hashing
+// to the given secure key, as returned by HashKey.
+func ValidatePreimage(hash common.Hash, preimage []byte) error {
+	have, err := HashKey(preimage)
+	if err != nil {
+		return err
+	}
+	if have != hash {
+		return fmt.Errorf("%w: have %x, want %x", ErrPreimageMismatch, have, hash)
+	}
+	return nil
+}
+
+// DecodeNode decodes the given node blob and checks that it hashes to the given
+// hash. The hash of the returned node is set, children of parent nodes are hash
+// nodes referencing the child blobs. A nil hash is rejected.
+func DecodeNode(hash *zkt.Hash, blob []byte) (TreeNode, error) {
+	if hash == nil {
+		return nil, errors.New("missing node hash")
+	}
+	node, err := NewTreeNodeFromBlob(blob)
+	if err != nil {
+		return nil, err
+	}
+	if err := ComputeNodeHash(NewHasher(), node, nil); err != nil {
+		return nil, err
+	}
+	if *node.Hash() != *hash {
+		return nil, fmt.Errorf("%w: have %x, want %x", ErrNodeHashMismatch, node.Hash().Bytes(), hash.Bytes())
+	}
+	return node, nil
+}
@@ -0,0 +1,91 @@
+package zk
+
+import (
+	"errors"
+	"testing"
+
+	zkt "github.com/kroma-network/zktrie/types"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestHashKey(t *testing.T) {
+	key := common.HexToAddress("0x4200000000000000000000000000000000000016")
+	hash := MustHashKey(key[:])
+	if want := common.BytesToHash(MustNewSecureHash(key[:]).Bytes()); hash != want {
+		t.Fatalf("hash mismatch: have %x, want %x", hash, want)
+	}
+	if err := ValidatePreimage(hash, key[:]); err != nil {
+		t.Fatalf("failed to validate preimage: %v", err)
+	}
+	other := common.HexToAddress("0x4200000000000000000000000000000000000015")
+	if err := ValidatePreimage(hash, other[:]); !errors.Is(err, ErrPreimageMismatch) {
+		t.Fatalf("unexpected error: have %v, want %v", err, ErrPreimageMismatch)
+	}
+}
+
+func TestDecodeNode(t *testing.T) {
+	tree := NewEmptyMerkleTree()
+	input := newTestInputFixedCount(100).applyZkTrees(tree)
+
+	blobs := make(map[zkt.Hash][]byte)
+	err := tree.ComputeAllNodeHash(func(node TreeNode) error {
+		blobs[*node.Hash()] = node.CanonicalValue()
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to compute node hashes: %v", err)
+	}
+	if len(blobs) < input.len() {
+		t.Fatalf("too few nodes: have %d, want at least %d", len(blobs), input.len())
+	}
+	for hash, blob := range blobs {
+		hash := hash
+		node, err := DecodeNode(&hash, blob)
+		if err != nil {
+			t.Fatalf("failed to decode node %x: %v", hash.Bytes(), err)
+		}
+		if *node.Hash() != hash {
+			t.Fatalf("node hash mismatch: have %x, want %x", node.Hash().Bytes(), hash.Bytes())
+		}
+	}
+	// A node must not be accepted under a different hash
+	for hash, blob := range blobs {
+		other := *zkt.NewHashFromBytes(common.Hash{0x1}.Bytes())
+		if _, err := DecodeNode(&other, blob); !errors.Is(err, ErrNodeHashMismatch) {
+			t.Fatalf("unexpected error for node %x: have %v, want %v", hash.Bytes(), err, ErrNodeHashMismatch)
+		}
+		break
+	}
+	if _, err := DecodeNode(&zkt.HashZero, nil); err == nil {
+		t.Fatal("expected error for empty blob")
+	}
+	for _, blob := range blobs {
+		if _, err := DecodeNode(nil, blob); err == nil {
+			t.Fatal("expected error for nil hash")
+		}
+		break
+	}
+}
+
+func BenchmarkValidatePreimage(b *testing.B) {
+	b.Run("account", func(b *testing.B) {
+		key := common.HexToAddress("0x4200000000000000000000000000000000000016")
+		benchmarkValidatePreimage(b, key[:])
+	})
+	b.Run("slot", func(b *testing.B) {
+		key := common.HexToHash("0x4200000000000000000000000000000000000000000000000000000000000016")
+		benchmarkValidatePreimage(b, key[:])
+	})
+}
+
+func benchmarkValidatePreimage(b *testing.B, preimage []byte) {
+	hash := MustHashKey(preimage)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := ValidatePreimage(hash, preimage); err != nil {
+			b.Fatal(err)
+		}
+	}
+}